@@ -1,62 +1,36 @@
 package mycli
 
 import (
-	"flag"
 	"fmt"
+	"os"
 	"strings"
 )
 
 // BashCompletionMain prints top-level flags and commands for shell completion.
 func BashCompletionMain(c *CLI) {
-
-	if flag.NArg() > 0 {
-		return
-	}
-
-	// get all flags and print them
-	for _, d := range c.Flgs {
-		low := strings.ToLower(d.GName())
-		// if this flag isn't hidden
-		if !d.GHidden() {
-			fmt.Fprintln(c.Writer, "-"+d.GName())
-		} else if low == "version" {
-			fmt.Fprintln(c.Writer, "-v,-version")
-		} else if low == "help" {
-			fmt.Fprintln(c.Writer, "-h,-help")
-		}
-	}
-	for _, d := range c.Cmds {
-		low := strings.ToLower(d.Name)
-		if !d.Hidden {
-			fmt.Fprintln(c.Writer, d.Name)
-		} else if low == "version" {
-			fmt.Fprintln(c.Writer, "v,version")
-		}
-	}
+	c.writeBashCompletions()
 }
 
-// BashCompletionSub prints flags and nested commands for a selected command.
+// BashCompletionSub prints flags, nested commands and argument values for a selected command.
 func BashCompletionSub(c *CLI, cm *CLICommand) {
+	c.writeBashCompletions()
+}
 
-	if cm.FS.NArg() > 0 {
-		return
-	}
-
-	for _, d := range cm.SubCommands {
-		low := strings.ToLower(d.Name)
-		if !d.Hidden {
-			fmt.Fprintln(c.Writer, d.Name)
-		} else if low == "version" {
-			fmt.Fprintln(c.Writer, "v,version")
+// writeBashCompletions answers the bash_autocomplete script, it passes the words before the cursor
+// followed by --generate-bash-completion and filters on the current word itself, so both flags and
+// the other candidates for the next word are printed.
+func (c *CLI) writeBashCompletions() {
+	args := make([]string, 0, len(os.Args))
+	for _, a := range os.Args[1:] {
+		if strings.TrimLeft(a, "-") != "generate-bash-completion" {
+			args = append(args, a)
 		}
 	}
-
-	for _, d := range cm.Flags {
-		low := strings.ToLower(d.GName())
-		if !d.GHidden() && low != "help" {
-			fmt.Fprintln(c.Writer, "-"+d.GName())
-		} else if low == "help" {
-			fmt.Fprintln(c.Writer, "-h,-help")
-		}
+	candidates := c.Completions(append(args, ""))
+	if c.completionContext(args).Flag == nil {
+		candidates = append(candidates, c.Completions(append(args, "-"))...)
+	}
+	for _, cd := range candidates {
+		fmt.Fprintln(c.Writer, cd.Value)
 	}
 }
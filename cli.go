@@ -9,7 +9,6 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"time"
 
@@ -20,10 +19,26 @@ const (
 	UseHTTPProxy  = "Sets http_proxy for network connections"
 	UseHTTPSProxy = "Sets https_proxy for network connections"
 	UseNoProxy    = "Sets no_proxy for network connections"
+	configUsage   = "config file path, repeat to merge several files with later files overriding earlier ones"
+)
+
+// Help categories used by the built-in flags, also available to applications
+const (
+	CategoryNetworking = "Networking"
+	CategoryDebugging  = "Debugging"
+	CategoryOutput     = "Output"
+	// CategoryConfiguration groups the flags selecting and reading config files
+	CategoryConfiguration = "Configuration"
 )
 
 var (
-	configfile             string
+	configfiles            StringList
+	configformat           string
+	noconfig               bool
+	printconfig            bool
+	configprofile          string
+	configkey              string
+	envfiles               StringList
 	ProxyHTTP              string
 	ProxyHTTPS             string
 	ProxyNO                string
@@ -71,6 +86,22 @@ type CLICommand struct {
 	help   bool
 	// SubCommands ability to create sub commands of a top command
 	SubCommands Commands
+	// Examples runnable invocations shown in the help for this command
+	Examples []Example
+	// Category groups this command under a heading in help
+	Category string
+	// Complete provides completion candidates for the command's positional arguments
+	Complete CompleteFunc
+	// builtin added by the library rather than the application
+	builtin bool
+}
+
+// Example pairs a runnable command line with a short explanation for help output.
+type Example struct {
+	// Cmd full command line to run, i.e. "myapp server -port 9090"
+	Cmd string
+	// Description what running Cmd accomplishes
+	Description string
 }
 
 // Commands is a convenience alias for a slice of CLICommand pointers.
@@ -88,7 +119,7 @@ func (c *CLICommand) RetrieveConfigValue(val *TomlWrapper, name string) error {
 	// set to Variable here so no need to go further as in other types
 	err = json.Unmarshal(wBytes, c.Variable)
 	if err != nil {
-		log.Fatalf("issue unmarshalling\n%+v", err)
+		return fmt.Errorf("%s: cannot read config into %T: %v", c.Name, c.Variable, err)
 	}
 
 	return nil
@@ -110,8 +141,10 @@ type AppInfo struct {
 	Title string
 	// Description detailed purpose of the application
 	Description string
-	Usage       string
-	Author      string
+	// Usage overrides the default usage line shown in help
+	Usage string
+	// Author name and/or contact of the author shown at the end of help
+	Author string
 	// Copyright typically company or developer copyright i.e. [ (c) 4-digit-year company/user ]
 	Copyright string
 }
@@ -172,9 +205,31 @@ type CLI struct {
 	fatalAdapter          FatalAdapter
 	usageAdapter          UsageAdapter
 	help, debug, version  bool
+	helpAll               bool
+	envVarsSet            bool
+	defaultsAdded         bool
 	varMap                map[string][]FieldPtr
 	DisableFlagValidation bool
 	ShowDuration          bool
+	// HelpWidth column help output wraps at, when 0 the COLUMNS environment variable is used and then 80
+	HelpWidth int
+	// Categories order of flag and command category headings in help, unlisted categories follow in order of appearance
+	Categories []string
+	// ConfigSearchPaths config files merged before any -config file when they exist, earlier paths are
+	// overridden by later ones, see DefaultConfigSearchPaths
+	ConfigSearchPaths []string
+	// ConfigDiscovery when no -config is given merge the file DiscoverConfigFile finds, -no-config turns it off
+	ConfigDiscovery bool
+	// StrictConfig makes Parse fail on config keys no flag or command reads and on values of the wrong
+	// type, otherwise those are logged and values of the wrong type are skipped, see ValidateConfig
+	StrictConfig bool
+	// EnvFiles dotenv files read into env lookup when they exist, later files win and the process
+	// environment wins over all of them, -env-file adds more
+	EnvFiles []string
+	// Sources where flags not set on the command line take their value from, the first source holding one
+	// wins, nil uses DefaultSources: env then config
+	Sources      []ValueSource
+	configLoaded bool
 }
 
 // NewCli creates an instance of the CLI application
@@ -218,6 +273,10 @@ func (c *CLI) addDefaultFlags() {
 		flg := c.setupHelpFlag()
 		dfFlgs = append(dfFlgs, flg)
 	}
+	if !c.findFlag("help-all", c.Flgs) {
+		flg := c.setupHelpAllFlag()
+		dfFlgs = append(dfFlgs, flg)
+	}
 	if !c.findFlag("debug", c.Flgs) {
 		flg := c.setupDebugFlag()
 		dfFlgs = append(dfFlgs, flg)
@@ -234,6 +293,30 @@ func (c *CLI) addDefaultFlags() {
 		flg := c.setupConfigFlag()
 		dfFlgs = append(dfFlgs, flg)
 	}
+	if !c.findFlag("config-format", c.Flgs) {
+		flg := c.setupConfigFormatFlag()
+		dfFlgs = append(dfFlgs, flg)
+	}
+	if !c.findFlag("no-config", c.Flgs) && (c.ConfigDiscovery || len(c.ConfigSearchPaths) > 0) {
+		flg := c.setupNoConfigFlag()
+		dfFlgs = append(dfFlgs, flg)
+	}
+	if !c.findFlag("print-config", c.Flgs) {
+		flg := c.setupPrintConfigFlag()
+		dfFlgs = append(dfFlgs, flg)
+	}
+	if !c.findFlag("profile", c.Flgs) {
+		flg := c.setupProfileFlag()
+		dfFlgs = append(dfFlgs, flg)
+	}
+	if !c.findFlag("env-file", c.Flgs) {
+		flg := c.setupEnvFileFlag()
+		dfFlgs = append(dfFlgs, flg)
+	}
+	if !c.findFlag("config-key", c.Flgs) {
+		flg := c.setupConfigKeyFlag()
+		dfFlgs = append(dfFlgs, flg)
+	}
 	if !c.findFlag("proxyhttp", c.Flgs) {
 		flgs := c.setupProxyFlags()
 		for _, f := range flgs {
@@ -248,8 +331,56 @@ func (c *CLI) addDefaultFlags() {
 	c.Flgs = dfFlgs
 }
 
+// addDefaults adds the built-in flags and commands once, generators call it so their output matches Parse
+func (c *CLI) addDefaults() {
+	if c.defaultsAdded {
+		return
+	}
+	c.defaultsAdded = true
+	c.addDefaultFlags()
+	c.addDefaultCmds()
+}
+
+// prepare completes the command tree outside of Parse with the built-in flags, commands and env var names
+func (c *CLI) prepare() {
+	c.addDefaults()
+	if !c.DisableEnvVars {
+		c.SetupEnvVars()
+	}
+}
+
+// addDefaultCmds appends the built-in commands the application has not defined itself
+func (c *CLI) addDefaultCmds() {
+	if c.Command("help") == nil {
+		c.Cmds = append(c.Cmds, builtinCmd(c.setupHelpCmd()))
+	}
+	if c.Command("generate-man") == nil {
+		c.Cmds = append(c.Cmds, builtinCmd(c.setupManCmd()))
+	}
+	if c.Command("generate-docs") == nil {
+		c.Cmds = append(c.Cmds, builtinCmd(c.setupDocsCmd()))
+	}
+	if c.Command("completion") == nil {
+		c.Cmds = append(c.Cmds, builtinCmd(c.setupCompletionCmd()))
+	}
+	if c.Command("config") == nil {
+		c.Cmds = append(c.Cmds, builtinCmd(c.setupConfigCmd()))
+	}
+}
+
+// builtinCmd marks a command as added by the library
+func builtinCmd(cmd *CLICommand) *CLICommand {
+	cmd.builtin = true
+	return cmd
+}
+
 // SetupEnvVars Loop through all Flags and Command Flags then set EnvVars based on Prefix and NAME or Override
 func (c *CLI) SetupEnvVars() {
+	// names already carry the prefix, running twice would prefix them again
+	if c.envVarsSet {
+		return
+	}
+	c.envVarsSet = true
 
 	tmp := make([]CLIFlag, 0)
 	for i, d := range c.Flgs {
@@ -356,99 +487,131 @@ func FixPath(path string) string {
 	return path
 }
 
-// parseConfigFile overlays TOML values onto flags that still hold their defaults.
+// parseConfigFile loads the config files and overlays the sources onto flags that still hold their defaults
 func (c *CLI) parseConfigFile() error {
-	debug := false
-	// no config file passed, return
-	if len(configfile) == 0 {
-		return nil
+	err := c.loadConfigFile()
+	if err != nil {
+		return err
 	}
-	// if doesn't exist return
-	if _, err := os.Stat(configfile); os.IsNotExist(err) {
-		log.Printf("!!! config file not found %v\n", configfile)
-		return nil
+	err = c.applySources(c.Flgs, "", true)
+	if err != nil {
+		return err
 	}
-	if len(strings.TrimSpace(configfile)) > 0 {
-		configfile = FixPath(configfile)
-		err := Toml().LoadToml(configfile)
+	for _, cmd := range c.Cmds {
+		err = c.applySources(cmd.Flags, cmd.Name+".", true)
 		if err != nil {
-			log.Printf("!!! issue loading config toml file %v\n", err)
 			return err
 		}
-		// find any missing values and set them from the tree
-		for _, f := range c.Flgs {
-			key := f.GName()
-			if Toml().Has(key) {
-				err = f.RetrieveConfigValue(Toml(), key)
-				if Err(err) {
-					log.Printf("!!! issue retrieving flag value from config toml file %v\n", err)
-					return err
-				}
-				if key == "debug" && f.GVariableToString() == "true" {
-					debug = true
-				}
-				if debug {
-					log.Printf("- config file has global flag %v value found of %v", key, f.GVariableToString())
-				}
+		for _, subcmd := range cmd.SubCommands {
+			err = c.applySources(subcmd.Flags, cmd.Name+"."+subcmd.Name+".", true)
+			if err != nil {
+				return err
 			}
 		}
-
-		for _, cmd := range c.Cmds {
-			for _, f := range cmd.Flags {
-				key := cmd.Name + "." + f.GName()
-				if Toml().Has(key) {
-					err = f.RetrieveConfigValue(Toml(), key)
-					if Err(err) {
-						log.Printf("!!! issue retrieving command value from config toml file %v\n", err)
-						return err
-					}
-					if debug {
-						log.Printf("- config file has command flag %v value found of %v", key, f.GVariableToString())
-					}
-				}
+		if cmd.Hidden && cmd.Variable != nil && c.configLoaded && c.hasConfigSource() && Toml().Has(cmd.Name) {
+			key := cmd.Name
+			err = cmd.RetrieveConfigValue(Toml(), key)
+			if Err(err) {
+				log.Printf("!!! issue retrieving config value from config file %v\n", err)
+				return err
 			}
-			for _, subcmd := range cmd.SubCommands {
-				for _, f := range subcmd.Flags {
-					key := cmd.Name + "." + subcmd.Name + "." + f.GName()
-					//log.Printf("- looking for %v", key)
-					if Toml().Has(key) {
-						err = f.RetrieveConfigValue(Toml(), key)
-						if Err(err) {
-							log.Printf("!!! issue retrieving command value from config toml file %v\n", err)
-							return err
-						}
-						if debug {
-							log.Printf("- config file has subcommand flag %v value found of %v", key, f.GVariableToString())
-						}
-					}
-				}
+			if Debug && !GenerateBashCompletion {
+				log.Printf("- config file has hidden command %v value found of %v", key, cmd.Variable)
 			}
-			if cmd.Hidden && Toml().Has(cmd.Name) {
-				key := cmd.Name
-				err = cmd.RetrieveConfigValue(Toml(), key)
-				if Err(err) {
-					log.Printf("!!! issue retrieving config value from config toml file %v\n", err)
-					return err
-				}
-				if debug {
-					log.Printf("- config file has hidden command %v value found of %v", key, cmd.Variable)
-				}
+		}
+	}
+	return nil
+}
+
+// loadConfigFile merges the config files, applies the profile and decrypts enc: values
+func (c *CLI) loadConfigFile() error {
+	c.configLoaded = false
+	files, err := c.configFiles()
+	if err != nil {
+		log.Printf("!!! %v\n", err)
+		return err
+	}
+	// no config file passed or found, return
+	if len(files) == 0 {
+		if len(configprofile) > 0 {
+			err = fmt.Errorf("profile %s selected but no config file was loaded", configprofile)
+			log.Printf("!!! %v\n", err)
+			return err
+		}
+		return nil
+	}
+	Toml().Reset()
+	for _, f := range files {
+		if Debug && !GenerateBashCompletion {
+			if f.discovered {
+				ng.Logf(ng.DEBUG, "config file %v (discovered)", f.path)
+			} else {
+				ng.Logf(ng.DEBUG, "config file %v", f.path)
+			}
+		}
+		err = Toml().Merge(f.path, f.format)
+		if err != nil {
+			log.Printf("!!! issue loading config file %v\n", err)
+			return err
+		}
+	}
+	if len(configprofile) > 0 {
+		if Debug && !GenerateBashCompletion {
+			ng.Logf(ng.DEBUG, "config profile %v", configprofile)
+		}
+		err = Toml().ApplyProfile(configprofile)
+		if err != nil {
+			log.Printf("!!! %v\n", err)
+			return err
+		}
+	}
+	cryptKey, err := c.configKey()
+	if err != nil {
+		log.Printf("!!! %v\n", err)
+		return err
+	}
+	if errs := Toml().DecryptValues(cryptKey); len(errs) > 0 {
+		for _, e := range errs {
+			log.Printf("!!! %v\n", e)
+		}
+		return ConfigErrors(errs)
+	}
+	if c.StrictConfig {
+		if errs := c.ValidateConfig(); len(errs) > 0 {
+			for _, e := range errs {
+				log.Printf("!!! %v\n", e)
 			}
+			return ConfigErrors(errs)
 		}
 	}
+	c.configLoaded = true
 	return nil
 }
 
+// readConfigValue reports whether the config has a value at key the flag can take, a value of the wrong
+// type is logged and left out so the flag keeps its value
+func (c *CLI) readConfigValue(f CLIFlag, key string) bool {
+	if !Toml().Has(key) {
+		return false
+	}
+	if err := checkConfigValue(f, key); err != nil {
+		log.Printf("!!! %v, ignored\n", err)
+		return false
+	}
+	return true
+}
+
 // Parse builds flag sets, overlays env/config values, and dispatches the matching action.
 func (c *CLI) Parse() error {
 	FlgValues = make(map[string]interface{})
+	GenerateBashCompletion = false
 	// add default flags, help, debug, debuglevel, version, config
 	var start time.Time
 	var ttlTime int64
 	if c.ShowDuration {
 		start = time.Now()
 	}
-	c.addDefaultFlags()
+	c.addDefaults()
 	if c.ShowDuration {
 		duration := time.Since(start)
 		ttlTime += duration.Nanoseconds()
@@ -481,6 +644,12 @@ func (c *CLI) Parse() error {
 	if c.ShowDuration {
 		start = time.Now()
 	}
+	// completion scripts call back with __complete, answer before any flag is parsed
+	if len(os.Args) > 1 && os.Args[1] == completeCmd {
+		GenerateBashCompletion = true
+		c.writeCompletions(c.Completions(os.Args[2:]))
+		return nil
+	}
 	// Pre process Global Flags
 	c.buildFlags(flag.CommandLine, c.Flgs, nil, "")
 	if c.ShowDuration {
@@ -514,14 +683,20 @@ func (c *CLI) Parse() error {
 	if c.ShowDuration {
 		start = time.Now()
 	}
-	err = c.retrieveEnvVal(c.Flgs)
+	err = c.loadEnvFiles()
+	if err != nil {
+		log.Printf("!!! issue loading env file %v\n", err)
+		return err
+	}
+	// config is not loaded yet, the other sources are read for PostGlblAction
+	err = c.applySources(c.Flgs, "", false)
 	if Err(err) {
 		return err
 	}
 	if c.ShowDuration {
 		duration := time.Since(start)
 		ttlTime += duration.Nanoseconds()
-		fmt.Printf("retrieveEnvVal: %vns\n", duration.Nanoseconds())
+		fmt.Printf("applySources: %vns\n", duration.Nanoseconds())
 	}
 
 	// loop Flags and find environment values, if not set on commandline set value to ENV value
@@ -604,52 +779,27 @@ func (c *CLI) Parse() error {
 		fmt.Printf("flag.Parse: %vns\n", duration.Nanoseconds())
 	}
 
-	//retrieve environment values if set and flag wasn't passed
-	if c.ShowDuration {
-		start = time.Now()
-	}
-	err = c.retrieveEnvVal(c.Flgs)
-	if Err(err) {
-		return err
-	}
-	if c.ShowDuration {
-		duration := time.Since(start)
-		ttlTime += duration.Nanoseconds()
-		fmt.Printf("c.retrieveEnvVal: %vns\n", duration.Nanoseconds())
-	}
-
+	// the flags choosing the config files come from the sources other than the config
 	if c.ShowDuration {
 		start = time.Now()
 	}
-	for _, d := range c.Cmds {
-		err = c.retrieveEnvVal(d.Flags)
-		if Err(err) {
-			return err
+	ctrl := make([]CLIFlag, 0, len(configControlFlags))
+	for _, f := range c.Flgs {
+		if configControlFlags[f.GName()] {
+			ctrl = append(ctrl, f)
 		}
 	}
-	if c.ShowDuration {
-		duration := time.Since(start)
-		ttlTime += duration.Nanoseconds()
-		fmt.Printf("for loop c.retrieveEnvVal: %vns\n", duration.Nanoseconds())
-	}
-	if c.ShowDuration {
-		start = time.Now()
-	}
-	for _, d := range c.Cmds {
-		for _, q := range d.SubCommands {
-			err = c.retrieveEnvVal(q.Flags)
-			if Err(err) {
-				return err
-			}
-		}
+	err = c.applySources(ctrl, "", false)
+	if Err(err) {
+		return err
 	}
 	if c.ShowDuration {
 		duration := time.Since(start)
 		ttlTime += duration.Nanoseconds()
-		fmt.Printf("for loop c.Cmds c.retrieveEnvVals: %vns\n", duration.Nanoseconds())
+		fmt.Printf("c.applySources: %vns\n", duration.Nanoseconds())
 	}
 
-	// anything not set use config file to set it
+	// anything not set on the command line comes from the sources in order
 	if c.ShowDuration {
 		start = time.Now()
 	}
@@ -675,7 +825,8 @@ func (c *CLI) Parse() error {
 	if c.ShowDuration {
 		start = time.Now()
 	}
-	if c.help && !GenerateBashCompletion {
+	// with a command, -help-all is handled once the command is found
+	if (c.help || c.helpAll && c.commandFor(os.Args[1:]) == nil) && !GenerateBashCompletion {
 		c.printUsage()
 		if c.ShowDuration {
 			duration := time.Since(start)
@@ -730,53 +881,7 @@ func (c *CLI) Parse() error {
 	}
 
 	// Process input
-	var parentCmd string
-	var subCmd string
-	var activeCmd *CLICommand
-	var pos int
-
-	var activePath string
-	for _, d := range c.Cmds {
-		for i, a := range os.Args {
-			if len(os.Args) > 1 && (a == strings.ToLower(d.Name) || a == strings.ToLower(d.ShortName)) {
-				activePath = strings.ToLower(d.Name)
-				pos = i + 1
-				t := d
-				c.cur = t
-				t.FS.Usage = c.flagSetUsage
-				//fmt.Printf("Args Size: %d and position 1 %v equals lowered name %s\n",len(os.Args), os.Args[1],strings.ToLower(d.Name))
-				activeCmd = t
-				parentCmd = t.Name
-				//log.Println("Active command ", t.Name)
-				// find subcommand to set instead of main command
-				subCs := make([]string, 0)
-				//foundSub := false
-				for _, k := range d.SubCommands {
-					subCs = append(subCs, k.Name)
-					//log.Println("check sub commands for ", d.Name, " is ", a, " = ", k.Name, " or ", k.ShortName)
-					for q, b := range os.Args {
-						if b == strings.ToLower(k.Name) || b == strings.ToLower(k.ShortName) {
-							activePath = activePath + "_" + strings.ToLower(k.Name)
-							pos = q + 1
-							t := k
-							c.cur = t
-							t.FS.Usage = c.flagSetUsage
-							//fmt.Printf("Args Size: %d and position 1 %v equals lowered name %s\n",len(os.Args), os.Args[1],strings.ToLower(d.Name))
-							activeCmd = t
-							subCmd = t.Name
-							//log.Println("Active sub command ", t.Name)
-							//foundSub = true
-						}
-					}
-				}
-				//if !foundSub {
-				//	t.Usage = strings.Join(subCs, ",")
-				//}
-
-				break
-			}
-		}
-	}
+	activeCmd, parent, cmdArgs := c.findCommand(os.Args[1:])
 
 	if activeCmd != nil {
 		//fmt.Printf("-- RUNNING ACTIVE CMD debug?: %v ; GenerateBaseComp %v\n", Debug, GenerateBashCompletion)
@@ -786,13 +891,15 @@ func (c *CLI) Parse() error {
 				fmt.Println("- Skipping Main Action and running requested Commands. -")
 				fmt.Println("")
 			}
-			if len(subCmd) > 0 {
-				fmt.Printf("Active command : %v %v\n", parentCmd, subCmd)
+			if parent != nil {
+				fmt.Printf("Active command : %v %v\n", parent.Name, activeCmd.Name)
 			} else {
 				fmt.Printf("Active command : %v\n", activeCmd.Name)
 			}
 		}
-		err = activeCmd.FS.Parse(os.Args[pos:])
+		c.cur = activeCmd
+		activeCmd.FS.Usage = c.flagSetUsage
+		err = activeCmd.FS.Parse(cmdArgs)
 		if err != nil {
 			return err
 		}
@@ -801,7 +908,8 @@ func (c *CLI) Parse() error {
 		if Err(err) {
 			return err
 		}
-		if activeCmd.help {
+		// help -help-all <command> runs the help command, it prints the target's help with hidden entries
+		if activeCmd.help || c.helpAll && !(activeCmd.builtin && activeCmd.Name == "help") {
 			c.usageAdapter.UsageText(activeCmd)
 			if c.TestMode {
 				return nil
@@ -845,6 +953,9 @@ func (c *CLI) Parse() error {
 			ng.Logln(ng.DEBUG, "**** End Target Flags ****")
 		}
 
+		if printconfig {
+			return c.WriteEffectiveConfig(c.Writer, "toml")
+		}
 		c.checkRequired(activeCmd.FS.Name(), activeCmd.Flags)
 		//Execute action
 		if activeCmd.PreAction != nil {
@@ -879,6 +990,8 @@ func (c *CLI) Parse() error {
 				return err
 			}
 		}
+	} else if printconfig {
+		return c.WriteEffectiveConfig(c.Writer, "toml")
 	} else if c.MainAction != nil {
 		//fmt.Println("-- RUNNING MAIN ACTION --")
 		//c.adjustFlagVars("", c.Flgs)
@@ -897,6 +1010,79 @@ func (c *CLI) Parse() error {
 	return nil
 }
 
+// commandFor the command findCommand resolves args to, nil when none
+func (c *CLI) commandFor(args []string) *CLICommand {
+	cmd, _, _ := c.findCommand(args)
+	return cmd
+}
+
+// findCommand finds the command to run and returns it, its parent when it is a subcommand, and the
+// arguments after it for its FlagSet. A built-in command must be the first positional argument and wins
+// there, so its arguments may name application commands. Otherwise an application command and its
+// subcommand are matched wherever they appear.
+func (c *CLI) findCommand(args []string) (*CLICommand, *CLICommand, []string) {
+	pos := flag.Args()
+	if len(pos) > 0 {
+		if cmd := matchCommand(c.Cmds, pos[0]); cmd != nil && cmd.builtin {
+			return c.resolveCommand(pos)
+		}
+	}
+	for _, d := range c.Cmds {
+		if d.builtin {
+			continue
+		}
+		for i, a := range args {
+			if !commandNamed(d, a) {
+				continue
+			}
+			cmd, parent, rest := d, (*CLICommand)(nil), args[i+1:]
+			// find subcommand to set instead of main command
+			for _, k := range d.SubCommands {
+				for q, b := range args {
+					if commandNamed(k, b) {
+						cmd, parent, rest = k, d, args[q+1:]
+					}
+				}
+			}
+			return cmd, parent, rest
+		}
+	}
+	return nil, nil, nil
+}
+
+// resolveCommand matches the first argument to a command and the second to one of its subcommands,
+// returning the active command, its parent when it is a subcommand, and the arguments left for its FlagSet.
+func (c *CLI) resolveCommand(args []string) (*CLICommand, *CLICommand, []string) {
+	if len(args) == 0 {
+		return nil, nil, nil
+	}
+	cmd := matchCommand(c.Cmds, args[0])
+	if cmd == nil {
+		return nil, nil, nil
+	}
+	if len(args) > 1 {
+		if sub := matchCommand(cmd.SubCommands, args[1]); sub != nil {
+			return sub, cmd, args[2:]
+		}
+	}
+	return cmd, nil, args[1:]
+}
+
+// matchCommand returns the command whose name or short name equals arg.
+func matchCommand(cmds []*CLICommand, arg string) *CLICommand {
+	for _, d := range cmds {
+		if commandNamed(d, arg) {
+			return d
+		}
+	}
+	return nil
+}
+
+// commandNamed reports whether arg is the command's name or short name
+func commandNamed(d *CLICommand, arg string) bool {
+	return arg == strings.ToLower(d.Name) || (len(d.ShortName) > 0 && arg == strings.ToLower(d.ShortName))
+}
+
 // runAction executes the supported hook and command action signatures.
 func runAction(act interface{}) error {
 	var err error
@@ -945,17 +1131,120 @@ func (c *CLI) Command(name string) *CLICommand {
 func (c *CLI) setupHelpFlag() CLIFlag {
 	return &BoolFlg{Variable: &c.help, Name: "help", ShortName: "h", Usage: "print commands", EnvVarExclude: true, Hidden: true}
 }
+func (c *CLI) setupHelpAllFlag() CLIFlag {
+	return &BoolFlg{Variable: &c.helpAll, Name: "help-all", Usage: "print commands including hidden flags and commands", EnvVarExclude: true, Hidden: true}
+}
+func (c *CLI) setupHelpCmd() *CLICommand {
+	cmd := &CLICommand{
+		Name:  "help",
+		Usage: "show help for the application or for a command, i.e. help <command> [subcommand]",
+		Examples: []Example{
+			{Cmd: c.appName() + " help", Description: "global help"},
+			{Cmd: c.appName() + " --help-all help <command>", Description: "command help including hidden flags"},
+		},
+	}
+	cmd.Action = func() error {
+		return c.showHelp(cmd.FS.Args())
+	}
+	cmd.Complete = func(ctx *CompletionContext, partial string) []string {
+		cmds := c.Cmds
+		for _, p := range ctx.Positionals {
+			found := matchCommand(cmds, p)
+			if found == nil {
+				return nil
+			}
+			cmds = found.SubCommands
+		}
+		names := make([]string, 0, len(cmds))
+		for _, d := range cmds {
+			if !d.Hidden {
+				names = append(names, d.Name)
+			}
+		}
+		return names
+	}
+	return cmd
+}
+
+// showHelp prints the global help, or the help of the command named by path
+func (c *CLI) showHelp(path []string) error {
+	if len(path) == 0 {
+		c.printUsage()
+		return nil
+	}
+	cmd, _, rest := c.resolveCommand(path)
+	if cmd == nil || len(rest) > 0 {
+		return fmt.Errorf("unknown command '%s'", strings.Join(path, " "))
+	}
+	fmt.Fprint(c.Writer, c.commandUsageText(cmd))
+	return nil
+}
+func (c *CLI) setupManCmd() *CLICommand {
+	var dir string
+	var perCommand bool
+	return &CLICommand{
+		Name:   "generate-man",
+		Usage:  "write roff man pages for the application and optionally each command",
+		Hidden: true,
+		Flags: []CLIFlag{
+			&StringFlg{Variable: &dir, Name: "dir", Usage: "directory the man pages are written to", Value: ".", EnvVarExclude: true, Complete: CompleteDirs},
+			&BoolFlg{Variable: &perCommand, Name: "commands", Usage: "also write one page per command", EnvVarExclude: true},
+		},
+		Action: func() error {
+			written, err := c.GenerateManPages(dir, perCommand)
+			for _, w := range written {
+				fmt.Fprintln(c.Writer, w)
+			}
+			return err
+		},
+	}
+}
+func (c *CLI) setupDocsCmd() *CLICommand {
+	var dir, format string
+	return &CLICommand{
+		Name:   "generate-docs",
+		Usage:  "write the command reference as one markdown file per command or a single html page",
+		Hidden: true,
+		Flags: []CLIFlag{
+			&StringFlg{Variable: &dir, Name: "dir", Usage: "directory the docs are written to", Value: ".", EnvVarExclude: true, Complete: CompleteDirs},
+			&StringFlg{Variable: &format, Name: "format", Usage: "output format", Value: "markdown", Options: []string{"markdown", "html"}, EnvVarExclude: true},
+		},
+		Action: func() error {
+			if format == "html" {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					return err
+				}
+				pth := filepath.Join(dir, c.appName()+".html")
+				f, err := os.Create(pth)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				if err = c.WriteHTMLDocs(f); err != nil {
+					return err
+				}
+				fmt.Fprintln(c.Writer, pth)
+				return nil
+			}
+			written, err := c.GenerateMarkdownDocs(dir)
+			for _, w := range written {
+				fmt.Fprintln(c.Writer, w)
+			}
+			return err
+		},
+	}
+}
 func (c *CLI) setupDebugFlag() CLIFlag {
-	return &BoolFlg{Variable: &Debug, Name: "debug", ShortName: "d", Usage: "flag set to debug", EnvVarExclude: true}
+	return &BoolFlg{Variable: &Debug, Name: "debug", ShortName: "d", Usage: "flag set to debug", EnvVarExclude: true, Category: CategoryDebugging}
 }
 func (c *CLI) IsDebug() bool {
 	return Debug
 }
 func (c *CLI) setupDebugLevelFlag() CLIFlag {
 	if !c.DisableEnvVars {
-		return &Int64Flg{Variable: &DebugLevel, Name: "debugLevel", ShortName: "dbglvl", Usage: "set debug level", EnvVar: "DEBUG_LEVEL", Value: 0}
+		return &Int64Flg{Variable: &DebugLevel, Name: "debugLevel", ShortName: "dbglvl", Usage: "set debug level", EnvVar: "DEBUG_LEVEL", Value: 0, Category: CategoryDebugging}
 	}
-	return &Int64Flg{Variable: &DebugLevel, Name: "debugLevel", ShortName: "dbglvl", Usage: "set debug level", EnvVarExclude: true, Value: 0}
+	return &Int64Flg{Variable: &DebugLevel, Name: "debugLevel", ShortName: "dbglvl", Usage: "set debug level", EnvVarExclude: true, Value: 0, Category: CategoryDebugging}
 }
 func (c *CLI) DebugLevel() int64 {
 	return DebugLevel
@@ -965,16 +1254,37 @@ func (c *CLI) setupVersionFlag() CLIFlag {
 }
 func (c *CLI) setupConfigFlag() CLIFlag {
 	if !c.DisableEnvVars {
-		return &StringFlg{Variable: &configfile, Name: "config", ShortName: "c", EnvVar: "config_filepath", Usage: "config file path"}
+		return &VarFlg{Variable: &configfiles, Name: "config", ShortName: "c", EnvVar: "config_filepath", Usage: configUsage, Repeat: true, Complete: CompleteFiles, Category: CategoryConfiguration}
 	}
-	return &StringFlg{Variable: &configfile, Name: "config", ShortName: "c", Usage: "config file path"}
+	return &VarFlg{Variable: &configfiles, Name: "config", ShortName: "c", Usage: configUsage, Repeat: true, Complete: CompleteFiles, Category: CategoryConfiguration}
+}
+func (c *CLI) setupNoConfigFlag() CLIFlag {
+	return &BoolFlg{Variable: &noconfig, Name: "no-config", Usage: "skip discovered and search path config files, -config files are still read", Category: CategoryConfiguration, Hidden: true}
+}
+func (c *CLI) setupPrintConfigFlag() CLIFlag {
+	return &BoolFlg{Variable: &printconfig, Name: "print-config", Usage: "print the value of every flag after command line, env and config are applied, as TOML, instead of running", EnvVarExclude: true, Category: CategoryConfiguration, Hidden: true}
+}
+func (c *CLI) setupProfileFlag() CLIFlag {
+	return &StringFlg{Variable: &configprofile, Name: "profile", Usage: "config profile to apply over the config files, read from their [profiles.<name>] table", Category: CategoryConfiguration, Hidden: true}
+}
+func (c *CLI) setupEnvFileFlag() CLIFlag {
+	return &VarFlg{Variable: &envfiles, Name: "env-file", Usage: "dotenv file to read env values from, the process environment wins over it", Repeat: true, Complete: CompleteFiles, EnvVarExclude: true, Category: CategoryConfiguration, Hidden: true}
+}
+func (c *CLI) setupConfigKeyFlag() CLIFlag {
+	if !c.DisableEnvVars {
+		return &StringFlg{Variable: &configkey, Name: "config-key", EnvVar: "config_key", Usage: "key file decrypting enc: config values", Complete: CompleteFiles, Category: CategoryConfiguration, Hidden: true}
+	}
+	return &StringFlg{Variable: &configkey, Name: "config-key", Usage: "key file decrypting enc: config values", Complete: CompleteFiles, Category: CategoryConfiguration, Hidden: true}
+}
+func (c *CLI) setupConfigFormatFlag() CLIFlag {
+	return &StringFlg{Variable: &configformat, Name: "config-format", Usage: "config file format, detected from the file extension when not set", Options: configFormats(), Category: CategoryConfiguration, Hidden: true}
 }
 func (c *CLI) setupProxyFlags() []CLIFlag {
 
 	return []CLIFlag{
-		&StringFlg{Variable: &ProxyHTTP, Name: "proxyhttp", EnvVar: "HTTP_PROXY", Usage: UseHTTPProxy},
-		&StringFlg{Variable: &ProxyHTTPS, Name: "proxyhttps", EnvVar: "HTTPS_PROXY", Usage: UseHTTPSProxy},
-		&StringFlg{Variable: &ProxyNO, Name: "noproxy", EnvVar: "NO_PROXY", Usage: UseNoProxy},
+		&StringFlg{Variable: &ProxyHTTP, Name: "proxyhttp", EnvVar: "HTTP_PROXY", Usage: UseHTTPProxy, Category: CategoryNetworking},
+		&StringFlg{Variable: &ProxyHTTPS, Name: "proxyhttps", EnvVar: "HTTPS_PROXY", Usage: UseHTTPSProxy, Category: CategoryNetworking},
+		&StringFlg{Variable: &ProxyNO, Name: "noproxy", EnvVar: "NO_PROXY", Usage: UseNoProxy, Category: CategoryNetworking},
 	}
 }
 func (c *CLI) IsProxySet() bool {
@@ -1017,6 +1327,9 @@ func (c *CLI) buildFlags(flgSet *flag.FlagSet, flgs []CLIFlag, cm *CLICommand, c
 	if cm != nil && !c.findFlag("help", flgs) {
 		flgs = append(flgs, &BoolFlg{Variable: &cm.help, Name: "help", ShortName: "h", Usage: "print commands"})
 	}
+	if cm != nil && !c.findFlag("help-all", flgs) {
+		flgs = append(flgs, &BoolFlg{Variable: &c.helpAll, Name: "help-all", Usage: "print commands including hidden flags and commands", Hidden: true})
+	}
 
 	// create our custom flag objects from parsing of the command line and our array of CLIFlag
 	for _, f := range flgs {
@@ -1093,7 +1406,11 @@ func (c *CLI) validateVariables() {
 			fmt.Println()
 			fmt.Println("!!!!!!!! WARNING (same &variable diff values, last will override) !!!!!!!!!!!!!")
 			for _, m := range y {
-				fmt.Printf("Multiple(%vx) use on \"Address of Variable for '%v' in command '%v' at '%v' - value: '%v'\"\n", len(y), m.FieldName, m.Command, m.Address, m.Value)
+				val := m.Value
+				if m.ValType == "secret" {
+					val = Redacted
+				}
+				fmt.Printf("Multiple(%vx) use on \"Address of Variable for '%v' in command '%v' at '%v' - value: '%v'\"\n", len(y), m.FieldName, m.Command, m.Address, val)
 			}
 			warned = true
 		}
@@ -1103,267 +1420,13 @@ func (c *CLI) validateVariables() {
 	}
 }
 
-func (c *CLI) flagSetUsage() {
-
-	var byt bytes.Buffer
-	byt.WriteString("Usage of ")
-	byt.WriteString(c.cur.Name)
-	byt.WriteString(":\t")
-	byt.WriteString("(" + c.cur.Usage + ")\n")
-	// create tmp array for sorting
-	subcmds := c.cur.SubCommands
-	// sort array
-	sort.Slice(subcmds, func(i, j int) bool {
-		return subcmds[i].Name < subcmds[j].Name
-	})
-	for i, sc := range subcmds {
-		if i > 0 {
-			byt.WriteString(",\n")
-		}
-		byt.WriteString("  " + sc.Name)
-	}
-
-	for _, f := range c.cur.Flags {
-		var s string
-		if len(strings.TrimSpace(f.GShortName())) > 0 {
-			s = fmt.Sprintf("      -%s, -%s", f.GName(), f.GShortName())
-		} else {
-			s = fmt.Sprintf("      -%s", f.GName())
-		}
-
-		name := f.UnquotedUsage()
-		if f.GRequired() {
-			name += "\t(REQUIRED_FLAG)"
-		}
-		if len(name) > 0 {
-			s += "  " + name
-		}
-		if tmp := fmt.Sprintf("%v", f.GOptions()); len(tmp) > 2 {
-			s += fmt.Sprintf("\n    \tOptions: %s", tmp)
-		}
-		// Boolean flags of one ASCII letter are so common we
-		// treat them specially, putting their usage on the same line.
-		if len(s) <= 4 { // space, space, '-', 'x'.
-			s += "\t"
-		} else {
-			// Four spaces before the tab triggers good alignment
-			// for both 4- and 8-space tab stops.
-			s += "\n    \t"
-		}
-		s += strings.Replace(f.GUsage(), "\n", "\n    \t", -1)
-		switch v := f.GValue().(type) {
-		case string:
-			if len(v) > 0 {
-				s += fmt.Sprintf(" (default %v)", f.GValue())
-			}
-		default:
-			s += fmt.Sprintf(" (default %v)", f.GValue())
-		}
-		s += "\n"
-		byt.WriteString(s)
-	}
-	fmt.Println(byt.String())
-}
-func (c *CLI) printUsage() {
-	szMin := 28
-	szMax := 29
-	flag.Usage = func() {
-		var byt bytes.Buffer
-		byt.WriteString("NAME:\n")
-		name := "myapp"
-		// if there is no path to the name use it, i.e. it's installed
-		if strings.Index(os.Args[0], string(filepath.Separator)) < 0 {
-			name = os.Args[0]
-		} else {
-			name = filepath.Base(os.Args[0])
-		}
-		byt.WriteString("  ")
-		byt.WriteString(name)
-		byt.WriteString("\n\n")
-
-		//USAGE
-		byt.WriteString("USAGE:\n")
-		byt.WriteString(fmt.Sprintf("  %s [global options] command [command options] [arguments...]\n\n", name))
-
-		if len(c.Flgs) > 0 {
-			byt.WriteString("GLOBAL OPTIONS:\n")
-			for _, f := range c.Flgs {
-				if f.GHidden() {
-					continue
-				}
-				var s string
-				if len(f.GShortName()) > 0 {
-					s = fmt.Sprintf("  -%s, -%s", f.GName(), f.GShortName())
-				} else {
-					s = fmt.Sprintf("  -%s", f.GName())
-				}
-
-				typeName := f.UnquotedUsage()
-				if len(typeName) > 0 {
-					s += "  " + typeName
-				}
-				if f.GRequired() {
-					if len(s) < szMin {
-						s += "\t\t(REQUIRED_FLAG)"
-					} else if len(s) <= szMax {
-						s += "\t(REQUIRED_FLAG)"
-					} else {
-						s += "  (REQUIRED_FLAG)"
-					}
-
-				}
-				if len(f.GEnvVar()) > 0 {
-					s += fmt.Sprintf("\n    %s\t(as environment var)", f.GEnvVar())
-				}
-				if tmp := fmt.Sprintf("%v", f.GOptions()); len(tmp) > 2 {
-					s += fmt.Sprintf("\n    \tOptions: %s", tmp)
-				}
-				// Boolean flags of one ASCII letter are so common we
-				// treat them specially, putting their usage on the same line.
-				if len(s) <= 4 { // space, space, '-', 'x'.
-					s += "\t"
-				} else {
-					// Four spaces before the tab triggers good alignment
-					// for both 4- and 8-space tab stops.
-					s += "\n    \t"
-				}
-				s += strings.Replace(f.GUsage(), "\n", "\n    \t", -1)
-				tmp := fmt.Sprintf("%v", f.GValue())
-				if len(tmp) > 0 {
-					s += fmt.Sprintf(" (default %s", tmp)
-					s += ")"
-				}
-				s += "\n\n"
-				byt.WriteString(s)
-			}
-			fmt.Println(byt.String())
-		}
-
-		if len(c.Cmds) > 0 {
-			byt.Reset()
-			byt.WriteString("COMMANDS:\n")
-			for _, d := range c.Cmds {
-				if d.Hidden {
-					continue
-				}
-				byt.WriteString(fmt.Sprintf("  %s", strings.ToLower(d.Name)))
-				if len(d.Usage) > 0 {
-					byt.WriteString(fmt.Sprintf(":    (%s)\n", strings.ToLower(d.Usage)))
-				}
-				for _, f := range d.Flags {
-					if f.GHidden() {
-						continue
-					}
-					var s string
-					if len(strings.TrimSpace(f.GShortName())) > 0 {
-						s = fmt.Sprintf("      -%s, -%s", f.GName(), f.GShortName())
-					} else {
-						s = fmt.Sprintf("      -%s", f.GName())
-					}
-
-					typeName := f.UnquotedUsage()
-					if len(typeName) > 0 {
-						s += "  " + typeName
-					}
-					if f.GRequired() {
-						if len(s) < szMin {
-							s += "\t\t(REQUIRED_FLAG)"
-						} else if len(s) <= szMax {
-							s += "\t(REQUIRED_FLAG)"
-						} else {
-							s += "  (REQUIRED_FLAG)"
-						}
-					}
-					if len(f.GEnvVar()) > 0 {
-						s += fmt.Sprintf("\n        %s\t(as environment var)", f.GEnvVar())
-					}
-					if tmp := fmt.Sprintf("%v", f.GOptions()); len(tmp) > 2 {
-						s += fmt.Sprintf("\n        \tOptions: %s", tmp)
-					}
-					// Boolean flags of one ASCII letter are so common we
-					// treat them specially, putting their usage on the same line.
-					if len(s) <= 4 { // space, space, '-', 'x'.
-						s += "\t"
-					} else {
-						// Four spaces before the tab triggers good alignment
-						// for both 4- and 8-space tab stops.
-						s += "\n    \t  "
-					}
-					s += strings.Replace(f.GUsage(), "\n", "\n    \t", -1)
-					tmp := fmt.Sprintf("%v", f.GValue())
-					if len(tmp) > 0 {
-						s += fmt.Sprintf(" (default %s", tmp)
-						s += ")"
-					}
-					s += "\n\n"
-					byt.WriteString(s)
-				}
-
-				// show subcommands here
-				for i, k := range d.SubCommands {
-					if i == 0 {
-						byt.WriteString("    \n    Sub Commands:\n")
-					}
-					if k.Hidden {
-						continue
-					}
-					byt.WriteString(fmt.Sprintf("      %s :\t%s\n", strings.ToLower(k.Name), strings.ToLower(k.Usage)))
-
-					for _, f := range k.Flags {
-						if f.GHidden() {
-							continue
-						}
-						var s string
-						if len(strings.TrimSpace(f.GShortName())) > 0 {
-							s = fmt.Sprintf("        -%s, -%s", f.GName(), f.GShortName())
-						} else {
-							s = fmt.Sprintf("        -%s", f.GName())
-						}
-
-						typeName := f.UnquotedUsage()
-						if len(typeName) > 0 {
-							s += "  " + typeName
-						}
-						if f.GRequired() {
-							if len(s) < szMin {
-								s += "\t\t(REQUIRED_FLAG)"
-							} else if len(s) <= szMax {
-								s += "\t(REQUIRED_FLAG)"
-							} else {
-								s += "  (REQUIRED_FLAG)"
-							}
-						}
-						if len(f.GEnvVar()) > 0 {
-							s += fmt.Sprintf("\n          %s\t(as environment var)", f.GEnvVar())
-						}
-						if tmp := fmt.Sprintf("%v", f.GOptions()); len(tmp) > 2 {
-							s += fmt.Sprintf("\n    \t    Options: %s", tmp)
-						}
-						// Boolean flags of one ASCII letter are so common we
-						// treat them specially, putting their usage on the same line.
-						if len(s) <= 4 { // space, space, '-', 'x'.
-							s += "\t"
-						} else {
-							// Four spaces before the tab triggers good alignment
-							// for both 4- and 8-space tab stops.
-							s += "\n    \t    "
-						}
-						s += strings.Replace(f.GUsage(), "\n", "\n    \t", -1)
-						tmp := fmt.Sprintf("%v", f.GValue())
-						if len(tmp) > 0 {
-							s += fmt.Sprintf(" (default %s", tmp)
-							s += ")"
-						}
-						s += "\n\n"
-						byt.WriteString(s)
-					}
-				}
-			}
-			fmt.Println(byt.String())
-		}
+// appName returns the executable name used in help, docs and completion output.
+func (c *CLI) appName() string {
+	// if there is no path to the name use it, i.e. it's installed
+	if strings.Index(os.Args[0], string(filepath.Separator)) < 0 {
+		return os.Args[0]
 	}
-	flag.Usage()
-
+	return filepath.Base(os.Args[0])
 }
 
 // ResetForTesting clears all flag state and sets the usage function as directed.
@@ -0,0 +1,156 @@
+package mycli
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CoerceError a config value that cannot be converted to the type a flag needs
+type CoerceError struct {
+	// Want the type that was needed, i.e. "an integer"
+	Want string
+	// Value the value found in the config
+	Value interface{}
+	// Reason optional detail on why the conversion failed
+	Reason string
+}
+
+func (e *CoerceError) Error() string {
+	got := configTypeName(e.Value)
+	switch e.Value.(type) {
+	case string:
+		got = fmt.Sprintf("string %q", e.Value)
+	case int64, uint64, float64:
+		got = fmt.Sprintf("%s %v", got, e.Value)
+	}
+	if len(e.Reason) > 0 {
+		return fmt.Sprintf("expected %s, got %s (%s)", e.Want, got, e.Reason)
+	}
+	return fmt.Sprintf("expected %s, got %s", e.Want, got)
+}
+
+// CoerceBool converts a config value to a bool, strings such as "true", "1" or "F" are parsed
+func CoerceBool(v interface{}) (bool, error) {
+	switch t := v.(type) {
+	case bool:
+		return t, nil
+	case string:
+		if b, err := strconv.ParseBool(strings.TrimSpace(t)); err == nil {
+			return b, nil
+		}
+	}
+	return false, &CoerceError{Want: "a boolean", Value: v}
+}
+
+// CoerceInt64 converts a config value to an int64, floats must be whole numbers and strings are
+// parsed as integers in Go syntax, i.e. "8080", "0x1F" or "1_000"
+func CoerceInt64(v interface{}) (int64, error) {
+	switch t := v.(type) {
+	case int64:
+		return t, nil
+	case uint64:
+		if t <= math.MaxInt64 {
+			return int64(t), nil
+		}
+		return 0, &CoerceError{Want: "an integer", Value: v, Reason: "out of range"}
+	case float64:
+		if t != math.Trunc(t) {
+			return 0, &CoerceError{Want: "an integer", Value: v, Reason: "not a whole number"}
+		}
+		if t < math.MinInt64 || t >= math.MaxInt64 {
+			return 0, &CoerceError{Want: "an integer", Value: v, Reason: "out of range"}
+		}
+		return int64(t), nil
+	case string:
+		if i, err := strconv.ParseInt(strings.TrimSpace(t), 0, 64); err == nil {
+			return i, nil
+		}
+	}
+	return 0, &CoerceError{Want: "an integer", Value: v}
+}
+
+// CoerceUint64 converts a config value to a uint64 like CoerceInt64, negative values are an error
+func CoerceUint64(v interface{}) (uint64, error) {
+	switch t := v.(type) {
+	case uint64:
+		return t, nil
+	case string:
+		if u, err := strconv.ParseUint(strings.TrimSpace(t), 0, 64); err == nil {
+			return u, nil
+		}
+	}
+	i, err := CoerceInt64(v)
+	if err != nil {
+		return 0, &CoerceError{Want: "a non-negative integer", Value: v}
+	}
+	if i < 0 {
+		return 0, &CoerceError{Want: "a non-negative integer", Value: v}
+	}
+	return uint64(i), nil
+}
+
+// CoerceFloat64 converts a config value to a float64, integers and numeric strings are converted
+func CoerceFloat64(v interface{}) (float64, error) {
+	switch t := v.(type) {
+	case float64:
+		return t, nil
+	case int64:
+		return float64(t), nil
+	case uint64:
+		return float64(t), nil
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(t), 64); err == nil {
+			return f, nil
+		}
+	}
+	return 0, &CoerceError{Want: "a float", Value: v}
+}
+
+// CoerceString returns a config string, other types are an error so a value such as port = 80 given
+// to a string flag is reported instead of silently formatted
+func CoerceString(v interface{}) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return "", &CoerceError{Want: "a string", Value: v}
+}
+
+// CoerceStringList converts an array of strings, or a comma separated string as on the command line, to a StringList
+func CoerceStringList(v interface{}) (StringList, error) {
+	switch t := v.(type) {
+	case StringList:
+		return t, nil
+	case []string:
+		return StringList(t), nil
+	case string:
+		return strings.Split(t, ","), nil
+	case []interface{}:
+		list := make(StringList, 0, len(t))
+		for i, el := range t {
+			s, ok := el.(string)
+			if !ok {
+				return nil, &CoerceError{Want: "an array of strings", Value: v, Reason: fmt.Sprintf("element %d is %s", i, configTypeName(el))}
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	}
+	return nil, &CoerceError{Want: "an array of strings", Value: v}
+}
+
+// CoerceMap returns a config table as a map with string keys
+func CoerceMap(v interface{}) (map[string]interface{}, error) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return t, nil
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[fmt.Sprint(k)] = val
+		}
+		return m, nil
+	}
+	return nil, &CoerceError{Want: "a table", Value: v}
+}
@@ -0,0 +1,95 @@
+package mycli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoerce(t *testing.T) {
+	b, err := CoerceBool("yes")
+	assert.EqualError(t, err, `expected a boolean, got string "yes"`)
+	b, err = CoerceBool(" true ")
+	assert.NoError(t, err)
+	assert.True(t, b)
+
+	i, err := CoerceInt64(float64(3))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), i)
+	i, err = CoerceInt64("0x10")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(16), i)
+	_, err = CoerceInt64(2.5)
+	assert.EqualError(t, err, "expected an integer, got float 2.5 (not a whole number)")
+	_, err = CoerceInt64(map[string]interface{}{})
+	assert.EqualError(t, err, "expected an integer, got table")
+
+	u, err := CoerceUint64("42")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), u)
+	_, err = CoerceUint64(int64(-1))
+	assert.EqualError(t, err, "expected a non-negative integer, got integer -1")
+
+	f, err := CoerceFloat64(int64(2))
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, f)
+	f, err = CoerceFloat64("1.5e3")
+	assert.NoError(t, err)
+	assert.Equal(t, 1500.0, f)
+
+	_, err = CoerceString(true)
+	assert.EqualError(t, err, "expected a string, got boolean")
+
+	l, err := CoerceStringList([]interface{}{"a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, StringList{"a", "b"}, l)
+	l, err = CoerceStringList("a,b")
+	assert.NoError(t, err)
+	assert.Equal(t, StringList{"a", "b"}, l)
+	_, err = CoerceStringList([]interface{}{"a", int64(1)})
+	assert.EqualError(t, err, "expected an array of strings, got array (element 1 is integer)")
+
+	m, err := CoerceMap(map[interface{}]interface{}{1: "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"1": "x"}, m)
+	_, err = CoerceMap([]interface{}{})
+	assert.EqualError(t, err, "expected a table, got array")
+}
+
+func TestRetrieveCoercedConfigValues(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	pth := writeConfig(t, t.TempDir(), "app.toml", "ratio = 2\nport = \"9090\"\nverbose = \"true\"\nhosts = [\"a\", \"b\"]\nlimit = 1.0\n")
+	var (
+		ratio   float64
+		port    int64
+		verbose bool
+		hosts   StringList
+		limit   uint64
+	)
+	cli = NewCli(nil, nil)
+	cli.TestMode = true
+	cli.StrictConfig = true
+	cli.Flgs = []CLIFlag{
+		&Float64Flg{Variable: &ratio, Name: "ratio", Value: 1.5},
+		&Int64Flg{Variable: &port, Name: "port", Value: 8080},
+		&BoolFlg{Variable: &verbose, Name: "verbose"},
+		&VarFlg{Variable: &hosts, Name: "hosts"},
+		&Uint64Flg{Variable: &limit, Name: "limit"},
+	}
+	cli.MainAction = func() {}
+	os.Args = []string{"cmd", "-c", pth}
+	assert.NoError(t, cli.Parse())
+	assert.Equal(t, 2.0, ratio)
+	assert.Equal(t, int64(9090), port)
+	assert.True(t, verbose)
+	assert.Equal(t, StringList{"a", "b"}, hosts)
+	assert.Equal(t, uint64(1), limit)
+
+	var tw TomlWrapper
+	assert.NoError(t, tw.Load(writeConfig(t, t.TempDir(), "bad.toml", "port = 1.5\n"), ""))
+	err := (&Int64Flg{Variable: &port, Name: "port"}).RetrieveConfigValue(&tw, "port")
+	assert.EqualError(t, err, "port: expected an integer, got float 1.5 (not a whole number)")
+}
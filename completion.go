@@ -0,0 +1,463 @@
+package mycli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// completeCmd is the argument shell scripts pass to call back into the application for candidates
+const completeCmd = "__complete"
+
+// Shells completion scripts can be generated for
+var Shells = []string{"bash", "zsh", "fish", "powershell"}
+
+// Completion a single completion candidate and the description shells that support it show beside it.
+type Completion struct {
+	Value       string
+	Description string
+}
+
+// CompleteFunc returns the values a flag or positional argument accepts, partial is the text typed so far.
+type CompleteFunc func(ctx *CompletionContext, partial string) []string
+
+// CompletionContext describes the command line a CompleteFunc is asked about.
+type CompletionContext struct {
+	CLI *CLI
+	// Command active command or subcommand, nil at the top level
+	Command *CLICommand
+	// Flag whose value is being completed, nil for positional arguments
+	Flag CLIFlag
+	// Args words typed before the one being completed
+	Args []string
+	// Used flags already on the line for the active command
+	Used []CLIFlag
+	// Positionals arguments of the active command typed so far, flags and their values excluded
+	Positionals []string
+	// Position index of the positional argument being completed, 0 for the first
+	Position int
+
+	flgs      []CLIFlag
+	cmds      []*CLICommand
+	flagsDone bool
+}
+
+// Completions returns the candidates for the last word of args, args being the words typed after the
+// program name with the word under the cursor last, empty when the cursor follows a space.
+func (c *CLI) Completions(args []string) []Completion {
+	c.prepare()
+	partial := ""
+	if len(args) > 0 {
+		partial = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+	// PowerShell drops empty arguments to native commands, its script sends "" instead
+	if partial == `""` {
+		partial = ""
+	}
+
+	ctx := c.completionContext(args)
+	if ctx.Flag != nil {
+		// bash splits -flag=value at the '=', the '=' itself becomes the word
+		if partial == "=" {
+			return prefixCompletions(valueCompletions(ctx, ctx.Flag, ""), "=")
+		}
+		return valueCompletions(ctx, ctx.Flag, partial)
+	}
+	if strings.HasPrefix(partial, "-") && !ctx.flagsDone {
+		// -flag=partial completes the value and keeps the flag in front of it
+		if idx := strings.Index(partial, "="); idx > 0 {
+			if f := lookupFlag(ctx.flgs, partial[:idx]); f != nil && takesValue(f) {
+				ctx.Flag = f
+				return prefixCompletions(valueCompletions(ctx, f, partial[idx+1:]), partial[:idx+1])
+			}
+			return []Completion{}
+		}
+		return filterCompletions(flagCompletions(unusedFlags(ctx.flgs, ctx.Used), ctx.Command == nil), partial)
+	}
+
+	candidates := make([]Completion, 0)
+	for _, d := range ctx.cmds {
+		if !d.Hidden {
+			candidates = append(candidates, Completion{Value: d.Name, Description: d.Usage})
+		}
+	}
+	if ctx.Command != nil && ctx.Command.Complete != nil {
+		for _, v := range ctx.Command.Complete(ctx, partial) {
+			candidates = append(candidates, Completion{Value: v})
+		}
+	}
+	return filterCompletions(candidates, partial)
+}
+
+// completionContext walks the words before the cursor the way Parse dispatches them: global flags,
+// the command, a subcommand directly after it, then the command's flags and positional arguments.
+func (c *CLI) completionContext(args []string) *CompletionContext {
+	ctx := &CompletionContext{CLI: c, Args: args, Used: make([]CLIFlag, 0), Positionals: make([]string, 0), flgs: c.Flgs, cmds: c.Cmds}
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" && !ctx.flagsDone {
+			ctx.flagsDone = true
+			continue
+		}
+		if len(a) > 1 && strings.HasPrefix(a, "-") && !ctx.flagsDone {
+			name := a
+			if idx := strings.Index(a, "="); idx > 0 {
+				name = a[:idx]
+			}
+			// a subcommand has to follow its command directly
+			if ctx.Command != nil {
+				ctx.cmds = nil
+			}
+			f := lookupFlag(ctx.flgs, name)
+			if f == nil {
+				continue
+			}
+			ctx.Used = append(ctx.Used, f)
+			if name != a || !takesValue(f) {
+				continue
+			}
+			// the value follows, bash splits "-flag=value" into "-flag", "=" and "value"
+			if i+1 < len(args) && args[i+1] == "=" {
+				i++
+			}
+			if i+1 >= len(args) {
+				ctx.Flag = f
+				break
+			}
+			i++
+			continue
+		}
+		if len(ctx.Positionals) == 0 {
+			if cmd := matchCommand(ctx.cmds, a); cmd != nil {
+				ctx.Command = cmd
+				ctx.flgs = cmd.Flags
+				ctx.cmds = cmd.SubCommands
+				ctx.Used = make([]CLIFlag, 0)
+				ctx.flagsDone = false
+				continue
+			}
+		}
+		ctx.Positionals = append(ctx.Positionals, a)
+		ctx.cmds = nil
+	}
+	ctx.Position = len(ctx.Positionals)
+	return ctx
+}
+
+// unusedFlags drops flags already on the line, Repeat flags collect every use and stay available.
+func unusedFlags(flgs []CLIFlag, used []CLIFlag) []CLIFlag {
+	tmp := make([]CLIFlag, 0, len(flgs))
+	for _, f := range flgs {
+		seen := false
+		if v, ok := f.(*VarFlg); !ok || !v.Repeat {
+			for _, u := range used {
+				if u == f {
+					seen = true
+					break
+				}
+			}
+		}
+		if !seen {
+			tmp = append(tmp, f)
+		}
+	}
+	return tmp
+}
+
+// valueCompletions offers the flag's Options followed by what its Complete provider returns.
+func valueCompletions(ctx *CompletionContext, f CLIFlag, partial string) []Completion {
+	candidates := make([]Completion, 0)
+	for _, v := range optionValues(f.GOptions()) {
+		candidates = append(candidates, Completion{Value: v})
+	}
+	if fn := f.GComplete(); fn != nil {
+		for _, v := range fn(ctx, partial) {
+			candidates = append(candidates, Completion{Value: v})
+		}
+	}
+	return filterCompletions(candidates, partial)
+}
+
+// optionValues turns a flag's Options into strings, options that are not plain values are skipped.
+func optionValues(options interface{}) []string {
+	values := make([]string, 0)
+	rv := reflect.ValueOf(options)
+	if rv.Kind() != reflect.Slice {
+		return values
+	}
+	for i := 0; i < rv.Len(); i++ {
+		el := rv.Index(i)
+		switch el.Kind() {
+		case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64, reflect.Float64:
+			values = append(values, fmt.Sprint(el.Interface()))
+		case reflect.Slice:
+			// list options such as StringList complete as comma separated values
+			parts := make([]string, 0, el.Len())
+			for j := 0; j < el.Len(); j++ {
+				if el.Index(j).Kind() != reflect.String {
+					break
+				}
+				parts = append(parts, el.Index(j).String())
+			}
+			if len(parts) == el.Len() && len(parts) > 0 {
+				values = append(values, strings.Join(parts, ","))
+			}
+		}
+	}
+	return values
+}
+
+// lookupFlag finds a flag by the word typed for it, i.e. "-port" or "--p".
+func lookupFlag(flgs []CLIFlag, word string) CLIFlag {
+	name := strings.TrimLeft(word, "-")
+	if len(name) == 0 {
+		return nil
+	}
+	for _, f := range flgs {
+		if f.GName() == name || (len(strings.TrimSpace(f.GShortName())) > 0 && f.GShortName() == name) {
+			return f
+		}
+	}
+	return nil
+}
+
+// takesValue reports whether the flag consumes the next word, bool flags do not.
+func takesValue(f CLIFlag) bool {
+	_, ok := f.(*BoolFlg)
+	return !ok
+}
+
+// CompleteFiles completes file and directory paths, directories end with a separator so they can be continued.
+func CompleteFiles(ctx *CompletionContext, partial string) []string {
+	return completePaths(partial, false)
+}
+
+// CompleteDirs completes directory paths only.
+func CompleteDirs(ctx *CompletionContext, partial string) []string {
+	return completePaths(partial, true)
+}
+
+func completePaths(partial string, dirsOnly bool) []string {
+	dir, base := filepath.Split(partial)
+	read := dir
+	if len(read) == 0 {
+		read = "."
+	}
+	entries, err := os.ReadDir(read)
+	if err != nil {
+		return nil
+	}
+	paths := make([]string, 0)
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		isDir := e.IsDir()
+		if e.Type()&os.ModeSymlink != 0 {
+			if fi, err := os.Stat(filepath.Join(read, name)); err == nil {
+				isDir = fi.IsDir()
+			}
+		}
+		if isDir {
+			paths = append(paths, dir+name+string(filepath.Separator))
+		} else if !dirsOnly {
+			paths = append(paths, dir+name)
+		}
+	}
+	return paths
+}
+
+// flagCompletions lists visible flags, help is offered everywhere and version at the top level.
+func flagCompletions(flgs []CLIFlag, global bool) []Completion {
+	candidates := make([]Completion, 0, len(flgs))
+	for _, f := range flgs {
+		name := strings.ToLower(f.GName())
+		if f.GHidden() && name != "help" && !(global && name == "version") {
+			continue
+		}
+		candidates = append(candidates, Completion{Value: "-" + f.GName(), Description: f.GUsage()})
+	}
+	if !global {
+		candidates = append(candidates, Completion{Value: "-help", Description: "print commands"})
+	}
+	return candidates
+}
+
+// prefixCompletions puts prefix in front of every candidate value.
+func prefixCompletions(candidates []Completion, prefix string) []Completion {
+	for i := range candidates {
+		candidates[i].Value = prefix + candidates[i].Value
+	}
+	return candidates
+}
+
+// filterCompletions keeps candidates starting with partial.
+func filterCompletions(candidates []Completion, partial string) []Completion {
+	tmp := make([]Completion, 0, len(candidates))
+	for _, cd := range candidates {
+		if strings.HasPrefix(cd.Value, partial) {
+			tmp = append(tmp, cd)
+		}
+	}
+	return tmp
+}
+
+// writeCompletions prints one candidate per line as value, tab, description.
+func (c *CLI) writeCompletions(candidates []Completion) {
+	for _, cd := range candidates {
+		desc := strings.Replace(firstLine(cd.Description), "\t", " ", -1)
+		if len(desc) > 0 {
+			fmt.Fprintf(c.Writer, "%s\t%s\n", cd.Value, desc)
+		} else {
+			fmt.Fprintln(c.Writer, cd.Value)
+		}
+	}
+}
+
+// WriteCompletionScript writes the completion script for shell, one of Shells. The script calls the
+// application back with __complete so candidates always match the installed binary.
+func (c *CLI) WriteCompletionScript(w io.Writer, shell string) error {
+	var tmpl string
+	switch shell {
+	case "bash":
+		tmpl = bashCompletionScript
+	case "zsh":
+		tmpl = zshCompletionScript
+	case "fish":
+		tmpl = fishCompletionScript
+	case "powershell":
+		tmpl = powershellCompletionScript
+	default:
+		return fmt.Errorf("unsupported shell '%s', supported shells are %v", shell, Shells)
+	}
+	name := c.appName()
+	r := strings.NewReplacer("{{app}}", name, "{{fn}}", completionFuncName(name), "{{complete}}", completeCmd)
+	_, err := io.WriteString(w, r.Replace(tmpl))
+	return err
+}
+
+func (c *CLI) setupCompletionCmd() *CLICommand {
+	cmd := &CLICommand{
+		Name:   "completion",
+		Usage:  "print the completion script for bash, zsh, fish or powershell, or install it for the current user",
+		Hidden: true,
+		Examples: []Example{
+			{Cmd: "source <(" + c.appName() + " completion bash)", Description: "enable completion in the current bash session"},
+			{Cmd: c.appName() + " completion fish | source", Description: "enable completion in the current fish session"},
+			{Cmd: c.appName() + " completion install", Description: "install completion for the login shell"},
+		},
+	}
+	cmd.Action = func() error {
+		return c.showHelp([]string{cmd.Name})
+	}
+	for _, sh := range Shells {
+		shell := sh
+		cmd.SubCommands = append(cmd.SubCommands, &CLICommand{
+			Name:  shell,
+			Usage: "print the " + shell + " completion script",
+			Action: func() error {
+				var byt bytes.Buffer
+				if err := c.WriteCompletionScript(&byt, shell); err != nil {
+					return err
+				}
+				_, err := c.Writer.Write(byt.Bytes())
+				return err
+			},
+		})
+	}
+	cmd.SubCommands = append(cmd.SubCommands, c.setupCompletionInstallCmds()...)
+	return cmd
+}
+
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// completionFuncName turns the executable name into a shell function name
+func completionFuncName(name string) string {
+	return "_" + nonIdentifier.ReplaceAllString(name, "_") + "_complete"
+}
+
+const bashCompletionScript = `# bash completion for {{app}}
+{{fn}}() {
+    local IFS=$'\n'
+    local line value
+    COMPREPLY=()
+    while read -r line; do
+        value="${line%%$'\t'*}"
+        [[ -n "$value" ]] && COMPREPLY+=("$value")
+    done < <("${COMP_WORDS[0]}" {{complete}} "${COMP_WORDS[@]:1:$COMP_CWORD}" 2>/dev/null)
+    # directories keep the cursor on the word so the path can be continued
+    if [[ ${#COMPREPLY[@]} -eq 1 && "${COMPREPLY[0]}" == */ ]]; then
+        compopt -o nospace 2>/dev/null
+    fi
+    return 0
+}
+complete -o default -F {{fn}} {{app}}
+`
+
+const zshCompletionScript = `#compdef {{app}}
+# zsh completion for {{app}}
+{{fn}}() {
+    local -a lines candidates dirs
+    local line value desc
+    lines=("${(@f)$(${words[1]} {{complete}} "${(@)words[2,$CURRENT]}" 2>/dev/null)}")
+    for line in $lines; do
+        [[ -z "$line" ]] && continue
+        value="${line%%$'\t'*}"
+        desc=""
+        [[ "$line" == *$'\t'* ]] && desc="${line#*$'\t'}"
+        value="${value//:/\\:}"
+        if [[ "$value" == */ ]]; then
+            dirs+=("${value}")
+        elif [[ -n "$desc" ]]; then
+            candidates+=("${value}:${desc}")
+        else
+            candidates+=("${value}")
+        fi
+    done
+    _describe -t values '{{app}}' candidates -Q
+    # directories keep the cursor on the word so the path can be continued
+    _describe -t paths 'paths' dirs -S '' -Q
+}
+if [[ "$funcstack[1]" == "{{fn}}" ]]; then
+    {{fn}} "$@"
+else
+    compdef {{fn}} {{app}}
+fi
+`
+
+const fishCompletionScript = `# fish completion for {{app}}
+function {{fn}}
+    set -l args (commandline -opc)
+    set -e args[1]
+    # quoted so an empty token is still passed as the word being completed
+    set -l token (commandline -ct)
+    {{app}} {{complete}} $args "$token" 2>/dev/null
+end
+complete -c {{app}} -f -a '({{fn}})'
+`
+
+const powershellCompletionScript = `# PowerShell completion for {{app}}
+Register-ArgumentCompleter -Native -CommandName '{{app}}' -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements |
+        Where-Object { $_.Extent.StartOffset -lt $cursorPosition } |
+        Select-Object -Skip 1 |
+        ForEach-Object { $_.ToString() })
+    if ($wordToComplete -eq '') {
+        # empty arguments are dropped when calling native commands
+        $words += '""'
+    }
+    & '{{app}}' {{complete}} @words 2>$null | ForEach-Object {
+        $parts = $_ -split "` + "`" + `t", 2
+        $desc = if ($parts.Count -gt 1 -and $parts[1]) { $parts[1] } else { $parts[0] }
+        [System.Management.Automation.CompletionResult]::new($parts[0], $parts[0], 'ParameterValue', $desc)
+    }
+}
+`
@@ -0,0 +1,214 @@
+package mycli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func completionValues(candidates []Completion) []string {
+	values := make([]string, 0, len(candidates))
+	for _, cd := range candidates {
+		values = append(values, cd.Value)
+	}
+	return values
+}
+
+func TestComplete(t *testing.T) {
+	c := manTestCli()
+
+	assert.Equal(t, []string{"weserve"}, completionValues(c.Completions([]string{"we"})))
+	assert.NotContains(t, completionValues(c.Completions([]string{""})), "secret")
+	assert.Equal(t, []string{"config"}, completionValues(c.Completions([]string{"weserve", ""})))
+	assert.Equal(t, []string{"-port"}, completionValues(c.Completions([]string{"weserve", "config", "-p"})))
+	assert.Contains(t, completionValues(c.Completions([]string{"weserve", "config", "-"})), "-help")
+	assert.Equal(t, []string{"config"}, completionValues(c.Completions([]string{"weserve", `""`})))
+
+	global := c.Completions([]string{"-cap"})
+	assert.Equal(t, []Completion{{Value: "-capture", Description: "capture mode"}}, global)
+}
+
+func TestCompleteCallback(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	var out bytes.Buffer
+	cli = manTestCli()
+	cli.TestMode = true
+	cli.Writer = &out
+
+	os.Args = []string{"cmd", completeCmd, "weserve", "config", "-app"}
+	err := cli.Parse()
+	assert.NoError(t, err)
+	assert.Equal(t, "-application\tapplication name\n", out.String())
+
+	// the shells pass an empty word when completing after a space
+	out.Reset()
+	ResetForTesting(nil)
+	os.Args = []string{"cmd", completeCmd, "weserve", ""}
+	err = cli.Parse()
+	assert.NoError(t, err)
+	assert.Equal(t, "config\tuse config file\n", out.String())
+
+	var byt bytes.Buffer
+	assert.NoError(t, cli.WriteCompletionScript(&byt, "fish"))
+	assert.Contains(t, byt.String(), `$args "$token"`)
+}
+
+func TestWriteCompletionScript(t *testing.T) {
+	c := manTestCli()
+	name := c.appName()
+	for _, sh := range Shells {
+		t.Run(sh, func(t *testing.T) {
+			var byt bytes.Buffer
+			err := c.WriteCompletionScript(&byt, sh)
+			assert.NoError(t, err)
+			assert.Contains(t, byt.String(), completeCmd)
+			assert.Contains(t, byt.String(), name)
+			assert.NotContains(t, byt.String(), "{{")
+		})
+	}
+
+	var byt bytes.Buffer
+	assert.Error(t, c.WriteCompletionScript(&byt, "tcsh"))
+}
+
+func TestCompletionsValues(t *testing.T) {
+	c := manTestCli()
+	var host string
+	c.Cmds[0].SubCommands[0].Flags = append(c.Cmds[0].SubCommands[0].Flags, &StringFlg{
+		Variable: &host, Name: "host", Usage: "remote host",
+		Complete: func(ctx *CompletionContext, partial string) []string {
+			assert.Equal(t, "config", ctx.Command.Name)
+			assert.Equal(t, "host", ctx.Flag.GName())
+			return []string{"host1", "host2", "other"}
+		},
+	})
+
+	assert.Equal(t, []string{"hello"}, completionValues(c.Completions([]string{"-capture", "he"})))
+	assert.Equal(t, []string{"hello", "bye"}, completionValues(c.Completions([]string{"-cap", ""})))
+	assert.Equal(t, []string{"-capture=bye"}, completionValues(c.Completions([]string{"-capture=b"})))
+	// bash splits -capture=b into -capture, = and b
+	assert.Equal(t, []string{"bye"}, completionValues(c.Completions([]string{"-capture", "=", "b"})))
+	assert.Equal(t, []string{"=hello", "=bye"}, completionValues(c.Completions([]string{"-capture", "="})))
+	assert.Equal(t, []string{"host1", "host2"}, completionValues(c.Completions([]string{"weserve", "config", "-host", "ho"})))
+}
+
+func TestCompleteFiles(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "conf.d"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), []byte(""), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden"), []byte(""), 0644))
+
+	prefix := dir + string(filepath.Separator)
+	assert.ElementsMatch(t, []string{prefix + "conf.d" + string(filepath.Separator), prefix + "config.toml"}, CompleteFiles(nil, prefix+"conf"))
+	assert.Equal(t, []string{prefix + "conf.d" + string(filepath.Separator)}, CompleteDirs(nil, prefix))
+	assert.Equal(t, []string{prefix + ".hidden"}, CompleteFiles(nil, prefix+"."))
+}
+
+func TestCompletionsLine(t *testing.T) {
+	c := manTestCli()
+	config := c.Cmds[0].SubCommands[0]
+	var position int
+	var positionals []string
+	config.Complete = func(ctx *CompletionContext, partial string) []string {
+		position = ctx.Position
+		positionals = ctx.Positionals
+		return []string{"first", "second"}
+	}
+
+	// flags already on the line are not offered again
+	assert.Equal(t, []string{"-application", "-help"}, completionValues(c.Completions([]string{"weserve", "config", "-port", "9000", "-"})))
+	// the previous flag expects a value, positionals are not offered
+	assert.Empty(t, c.Completions([]string{"weserve", "config", "-port", ""}))
+	// a flag value is not mistaken for a command or a positional
+	assert.Equal(t, []string{"first", "second"}, completionValues(c.Completions([]string{"-capture", "weserve", "weserve", "config", "-port", "9000", ""})))
+	assert.Equal(t, 0, position)
+
+	completionValues(c.Completions([]string{"weserve", "config", "a.txt", "-port", "9000", "b.txt", ""}))
+	assert.Equal(t, 2, position)
+	assert.Equal(t, []string{"a.txt", "b.txt"}, positionals)
+
+	// everything after -- is positional
+	assert.Equal(t, []string{}, completionValues(c.Completions([]string{"weserve", "config", "--", "-"})))
+	// help completes the command path it is given
+	assert.Equal(t, []string{"config"}, completionValues(c.Completions([]string{"help", "weserve", ""})))
+}
+
+func TestBashCompletionSub(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	var out bytes.Buffer
+	cli = manTestCli()
+	cli.TestMode = true
+	cli.Writer = &out
+
+	os.Args = []string{"cmd", "weserve", "config", "-application", "x", "-generate-bash-completion"}
+	BashCompletionSub(cli, cli.Cmds[0].SubCommands[0])
+	assert.Equal(t, "-port\n-help\n", out.String())
+}
+
+func TestCompletionInstall(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("SHELL", "/bin/bash")
+
+	var out bytes.Buffer
+	cli = manTestCli()
+	cli.TestMode = true
+	cli.Writer = &out
+	os.Args = []string{"cmd", "completion", "install"}
+	assert.NoError(t, cli.Parse())
+
+	pth := filepath.Join(home, ".local", "share", "bash-completion", "completions", cli.appName())
+	assert.FileExists(t, pth)
+	assert.Contains(t, out.String(), "installed bash completion to "+pth)
+
+	out.Reset()
+	ResetForTesting(nil)
+	os.Args = []string{"cmd", "completion", "install", "-shell", "zsh"}
+	assert.NoError(t, cli.Parse())
+	zshDir := filepath.Join(home, ".zsh", "completions")
+	assert.FileExists(t, filepath.Join(zshDir, "_"+cli.appName()))
+	assert.Contains(t, out.String(), "add 'fpath=("+zshDir+" $fpath)' before compinit")
+	t.Setenv("ZDOTDIR", filepath.Join(home, ".config", "zsh"))
+	zsh, err := cli.CompletionPath("zsh")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".config", "zsh", "completions", "_"+cli.appName()), zsh)
+
+	fish, err := cli.InstallCompletion("fish")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".config", "fish", "completions", cli.appName()+".fish"), fish)
+
+	removed, err := cli.UninstallCompletion("bash")
+	assert.NoError(t, err)
+	assert.Equal(t, pth, removed)
+	assert.NoFileExists(t, pth)
+	_, err = cli.UninstallCompletion("bash")
+	assert.Error(t, err)
+
+	_, err = cli.InstallCompletion("powershell")
+	assert.Error(t, err)
+
+	// an application install command does not capture completion install
+	var ran bool
+	out.Reset()
+	ResetForTesting(nil)
+	cli = manTestCli()
+	cli.TestMode = true
+	cli.Writer = &out
+	cli.Cmds = append(cli.Cmds, &CLICommand{Name: "install", Action: func() { ran = true }})
+	os.Args = []string{"cmd", "completion", "install", "-shell", "fish"}
+	assert.NoError(t, cli.Parse())
+	assert.False(t, ran)
+	assert.Contains(t, out.String(), "installed fish completion to "+fish)
+}
@@ -0,0 +1,143 @@
+package mycli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// installShells shells whose completion scripts can be installed into a per-user directory
+var installShells = []string{"bash", "zsh", "fish"}
+
+// DetectShell returns the user's login shell from $SHELL, i.e. "zsh" for /bin/zsh.
+func DetectShell() string {
+	return filepath.Base(os.Getenv("SHELL"))
+}
+
+// CompletionPath returns where InstallCompletion writes the script for shell, named after the executable.
+func (c *CLI) CompletionPath(shell string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	name := c.appName()
+	switch shell {
+	case "bash":
+		return filepath.Join(dataHome(home), "bash-completion", "completions", name), nil
+	case "zsh":
+		return filepath.Join(zshCompletionDir(home), "_"+name), nil
+	case "fish":
+		return filepath.Join(configHome(home), "fish", "completions", name+".fish"), nil
+	case "powershell":
+		return "", fmt.Errorf("powershell completion cannot be installed, add '%s completion powershell | Out-String | Invoke-Expression' to $PROFILE", name)
+	}
+	return "", fmt.Errorf("unsupported shell '%s', supported shells are %v", shell, installShells)
+}
+
+// InstallCompletion writes the completion script for shell to its per-user location and returns the path.
+func (c *CLI) InstallCompletion(shell string) (string, error) {
+	pth, err := c.CompletionPath(shell)
+	if err != nil {
+		return "", err
+	}
+	var byt bytes.Buffer
+	if err = c.WriteCompletionScript(&byt, shell); err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+		return "", err
+	}
+	if err = os.WriteFile(pth, byt.Bytes(), 0644); err != nil {
+		return "", err
+	}
+	return pth, nil
+}
+
+// UninstallCompletion removes the script InstallCompletion wrote for shell and returns its path.
+func (c *CLI) UninstallCompletion(shell string) (string, error) {
+	pth, err := c.CompletionPath(shell)
+	if err != nil {
+		return "", err
+	}
+	if err = os.Remove(pth); err != nil {
+		if os.IsNotExist(err) {
+			return pth, fmt.Errorf("no %s completion installed at %s", shell, pth)
+		}
+		return pth, err
+	}
+	return pth, nil
+}
+
+func (c *CLI) setupCompletionInstallCmds() []*CLICommand {
+	var installShell, uninstallShell string
+	install := &CLICommand{
+		Name:  "install",
+		Usage: "install the completion script for the current user, the shell is taken from $SHELL unless -shell is set",
+		Flags: []CLIFlag{
+			&StringFlg{Variable: &installShell, Name: "shell", Usage: "shell to install completion for", Options: installShells, EnvVarExclude: true},
+		},
+	}
+	install.Action = func() error {
+		shell := completionShell(installShell)
+		pth, err := c.InstallCompletion(shell)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.Writer, "installed %s completion to %s\n", shell, pth)
+		if shell == "zsh" {
+			fmt.Fprintf(c.Writer, "add 'fpath=(%s $fpath)' before compinit in your .zshrc if it is not there yet\n", filepath.Dir(pth))
+		}
+		fmt.Fprintln(c.Writer, "start a new shell to use it")
+		return nil
+	}
+	uninstall := &CLICommand{
+		Name:  "uninstall",
+		Usage: "remove the completion script installed by completion install",
+		Flags: []CLIFlag{
+			&StringFlg{Variable: &uninstallShell, Name: "shell", Usage: "shell to remove completion for", Options: installShells, EnvVarExclude: true},
+		},
+	}
+	uninstall.Action = func() error {
+		shell := completionShell(uninstallShell)
+		pth, err := c.UninstallCompletion(shell)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.Writer, "removed %s completion from %s\n", shell, pth)
+		return nil
+	}
+	return []*CLICommand{install, uninstall}
+}
+
+// completionShell uses the shell passed with -shell, otherwise the login shell
+func completionShell(shell string) string {
+	if len(shell) > 0 {
+		return shell
+	}
+	return DetectShell()
+}
+
+// zshCompletionDir $ZDOTDIR/completions or ~/.zsh/completions, the user adds it to fpath
+func zshCompletionDir(home string) string {
+	if dir := os.Getenv("ZDOTDIR"); len(dir) > 0 {
+		return filepath.Join(dir, "completions")
+	}
+	return filepath.Join(home, ".zsh", "completions")
+}
+
+// dataHome $XDG_DATA_HOME or ~/.local/share
+func dataHome(home string) string {
+	if dir := os.Getenv("XDG_DATA_HOME"); len(dir) > 0 {
+		return dir
+	}
+	return filepath.Join(home, ".local", "share")
+}
+
+// configHome $XDG_CONFIG_HOME or ~/.config
+func configHome(home string) string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); len(dir) > 0 {
+		return dir
+	}
+	return filepath.Join(home, ".config")
+}
@@ -1,11 +1,14 @@
 package mycli
 
 import (
-	"github.com/pelletier/go-toml/v2"
-	"log"
+	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 var once sync.Once
@@ -25,32 +28,451 @@ func Toml() *TomlWrapper {
 // TomlWrapper stores parsed TOML data as a nested map.
 type TomlWrapper struct {
 	Map map[string]interface{}
+	// Files config files merged into Map, in load order
+	Files []string
+	// sources file that supplied each value, by dotted path
+	sources map[string]string
+	// positions of the keys of each merged file, by file and dotted path
+	positions map[string]map[string]ConfigPosition
+	// edits changes made by Set and Delete, replayed on a file by Save
+	edits []configEdit
+	// Profile the profile ApplyProfile overlaid, empty when none was
+	Profile string
+	// profiles profile that set each value, by dotted path
+	profiles map[string]string
+	// encrypted dotted paths DecryptValues decrypted, values in arrays are recorded on the array
+	encrypted map[string]bool
 }
 
+// ProfilesKey the table of a config file holding its named profiles
+const ProfilesKey = "profiles"
+
 // LoadToml reads and unmarshals a TOML document into the wrapper map.
 func (t *TomlWrapper) LoadToml(path string) error {
+	return t.Load(path, "toml")
+}
+
+// Load reads a config document into the wrapper map using the loader registered for format,
+// an empty format is detected from the file extension. Values loaded before are dropped.
+func (t *TomlWrapper) Load(path, format string) error {
+	t.Reset()
+	return t.Merge(path, format)
+}
+
+// Reset drops all loaded values and files.
+func (t *TomlWrapper) Reset() {
+	t.Map = make(map[string]interface{})
+	t.Files = nil
+	t.sources = make(map[string]string)
+	t.positions = make(map[string]map[string]ConfigPosition)
+	t.edits = nil
+	t.Profile = ""
+	t.profiles = make(map[string]string)
+	t.encrypted = make(map[string]bool)
+}
+
+// Merge reads a config document like Load and merges it over the values already loaded. Tables are
+// merged key by key, any other value, arrays included, replaces the earlier one. Files listed in its
+// include key are merged first, and string values are expanded with ExpandConfigString.
+func (t *TomlWrapper) Merge(path, format string) error {
+	return t.merge(path, format, nil)
+}
+
+// merge loads path after its includes, stack holds the files including it
+func (t *TomlWrapper) merge(path, format string, stack []string) error {
+	for _, p := range stack {
+		if p == path {
+			return fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), path)
+		}
+	}
+	if len(format) == 0 {
+		format = ConfigFormat(path)
+	}
+	loader, ok := ConfigLoaders[format]
+	if !ok {
+		return fmt.Errorf("unsupported config format '%s', supported formats are %v", format, configFormats())
+	}
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
+	m, err := loader(data)
+	if err != nil {
+		return fmt.Errorf("issue loading %s config file %s\n%v", format, path, err)
+	}
+	if t.Map == nil || t.sources == nil || t.positions == nil {
+		t.Reset()
+	}
+	includes, err := configIncludes(m, path)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	for _, inc := range includes {
+		if _, err = os.Stat(inc); err != nil {
+			return fmt.Errorf("%s: include %s: %v", path, inc, err)
+		}
+		if err = t.merge(inc, "", append(stack, path)); err != nil {
+			return err
+		}
+	}
+	expandConfigMap(m)
+	mergeConfig(t.Map, m, "", path, t.sources)
+	t.Files = append(t.Files, path)
+	if locate, ok := configLocators[format]; ok {
+		t.positions[path] = locate(data)
+	}
+	return nil
+}
+
+// Source returns the file that supplied the value at a dotted path, empty when no file set it.
+func (t *TomlWrapper) Source(key string) string {
+	keys := strings.Split(stripSelectors(key), ".")
+	// values inside arrays are recorded on the array
+	for i := len(keys); i > 0; i-- {
+		if src, ok := t.sources[strings.Join(keys[:i], ".")]; ok {
+			return src
+		}
+	}
+	return ""
+}
+
+// Set changes the value at a dotted path, creating the tables on the way, and records the change for
+// Save. Values are scalars or arrays, a table is changed one key at a time, and paths take no selectors.
+func (t *TomlWrapper) Set(key string, value interface{}) error {
+	keys, err := editPath(key)
+	if err != nil {
+		return err
+	}
+	v, err := setValue(value)
+	if err != nil {
+		return fmt.Errorf("%s: %v", key, err)
+	}
+	if t.Map == nil || t.sources == nil || t.positions == nil {
+		t.Reset()
+	}
+	tbl := t.Map
+	for i, k := range keys[:len(keys)-1] {
+		node, ok := tbl[k]
+		if !ok {
+			m := make(map[string]interface{})
+			tbl[k] = m
+			tbl = m
+			continue
+		}
+		if tbl, err = editTable(node, keys[:i+1]); err != nil {
+			return err
+		}
+	}
+	last := keys[len(keys)-1]
+	if _, ok := tbl[last].(map[string]interface{}); ok {
+		return fmt.Errorf("%s is a table, set its keys instead", key)
+	}
+	tbl[last] = v
+	t.edits = append(t.edits, configEdit{keys: keys, value: v})
+	return nil
+}
+
+// Delete removes the value or table at a dotted path and records the change for Save.
+func (t *TomlWrapper) Delete(key string) error {
+	keys, err := editPath(key)
+	if err != nil {
+		return err
+	}
+	tbl := t.Map
+	for i, k := range keys[:len(keys)-1] {
+		node, ok := tbl[k]
+		if !ok {
+			return fmt.Errorf("%s: not found", strings.Join(keys[:i+1], "."))
+		}
+		if tbl, err = editTable(node, keys[:i+1]); err != nil {
+			return err
+		}
+	}
+	if _, ok := tbl[keys[len(keys)-1]]; !ok {
+		return fmt.Errorf("%s: not found", key)
+	}
+	delete(tbl, keys[len(keys)-1])
+	for p := range t.sources {
+		if p == key || strings.HasPrefix(p, key+".") {
+			delete(t.sources, p)
+		}
+	}
+	t.edits = append(t.edits, configEdit{keys: keys, delete: true})
+	return nil
+}
+
+// Save writes the changes made by Set and Delete to the file at path in the format of its extension. The
+// file is read again and only the changed keys are rewritten, so comments, blank lines and key order are
+// kept; JSON is re-indented. A missing file is created.
+func (t *TomlWrapper) Save(path string) error {
+	format := ConfigFormat(path)
+	edit, ok := configEditors[format]
+	if !ok {
+		return fmt.Errorf("saving %s config files is not supported", format)
+	}
+	mode := os.FileMode(0644)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	for _, e := range t.edits {
+		if data, err = edit(data, e); err != nil {
+			return fmt.Errorf("issue saving %s config file %s\n%v", format, path, err)
+		}
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err = os.WriteFile(path, data, mode); err != nil {
+		return err
+	}
+	t.edits = nil
+	return nil
+}
 
-	err = toml.Unmarshal(data, &t.Map)
+// editPath splits a key for Set and Delete, which address one key and take no selectors
+func editPath(key string) ([]string, error) {
+	keys, err := splitPath(key)
 	if err != nil {
-		log.Fatalf("issue loading toml file\n%+v", err)
+		return nil, err
 	}
+	for _, k := range keys {
+		if len(k) == 0 || strings.ContainsAny(k, "[]") {
+			return nil, fmt.Errorf("%s: expected a dotted path without selectors", key)
+		}
+	}
+	return keys, nil
+}
+
+// editTable the table a path walks through, the last element of an array of tables like Get reads
+func editTable(node interface{}, keys []string) (map[string]interface{}, error) {
+	if arr, ok := node.([]interface{}); ok && len(arr) > 0 {
+		node = arr[len(arr)-1]
+	}
+	tbl, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s is %s, not a table", strings.Join(keys, "."), configTypeName(node))
+	}
+	return tbl, nil
+}
+
+// setValue converts a value for Set to the types the loaders produce, tables are refused
+func setValue(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case StringList:
+		v = []string(val)
+	case uint:
+		v = uint64(val)
+	case int8:
+		v = int64(val)
+	case int16:
+		v = int64(val)
+	case uint8:
+		v = int64(val)
+	case uint16:
+		v = int64(val)
+	case uint32:
+		v = int64(val)
+	}
+	if l, ok := v.([]string); ok {
+		arr := make([]interface{}, len(l))
+		for i, s := range l {
+			arr[i] = s
+		}
+		v = arr
+	}
+	n, err := normalizeConfigValue(v)
+	if err != nil {
+		return nil, err
+	}
+	switch val := n.(type) {
+	case bool, int64, float64, string:
+		return n, nil
+	case []interface{}:
+		for i, el := range val {
+			if val[i], err = setValue(el); err != nil {
+				return nil, err
+			}
+		}
+		return val, nil
+	}
+	return nil, fmt.Errorf("cannot set %s, only scalars and arrays", configTypeName(n))
+}
+
+// ApplyProfile overlays the table profiles.<name> on the loaded values, key by key like a later file. A
+// profile naming another in extends is applied over that one. The values it sets keep the file that
+// holds the profile as their Source, and Provenance names the profile.
+func (t *TomlWrapper) ApplyProfile(name string) error {
+	chain, err := t.profileChain(name)
+	if err != nil {
+		return err
+	}
+	if t.profiles == nil {
+		t.profiles = make(map[string]string)
+	}
+	profiles := t.Map[ProfilesKey].(map[string]interface{})
+	for _, p := range chain {
+		tbl := make(map[string]interface{})
+		for k, v := range profiles[p].(map[string]interface{}) {
+			if k != "extends" {
+				tbl[k] = v
+			}
+		}
+		set := make(map[string]string)
+		mergeConfig(t.Map, tbl, "", p, set)
+		for key := range set {
+			// a replaced table no longer holds values from files or earlier profiles
+			for k := range t.sources {
+				if strings.HasPrefix(k, key+".") {
+					delete(t.sources, k)
+					delete(t.profiles, k)
+				}
+			}
+			t.sources[key] = t.Source(ProfilesKey + "." + p + "." + key)
+			t.profiles[key] = p
+		}
+	}
+	t.Profile = name
 	return nil
 }
 
+// profileChain the profiles to apply for name, the one it extends first
+func (t *TomlWrapper) profileChain(name string) ([]string, error) {
+	profiles, _ := t.Map[ProfilesKey].(map[string]interface{})
+	var chain []string
+	seen := make(map[string]bool)
+	for p := name; len(p) > 0; {
+		if seen[p] {
+			return nil, fmt.Errorf("profile %s extends itself: %s -> %s", name, strings.Join(reversed(chain), " -> "), p)
+		}
+		seen[p] = true
+		tbl, ok := profiles[p].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("profile %s not found in %s", p, strings.Join(t.Files, ", "))
+		}
+		chain = append([]string{p}, chain...)
+		ext, ok := tbl["extends"]
+		if !ok {
+			break
+		}
+		if p, ok = ext.(string); !ok {
+			return nil, fmt.Errorf("%s.%s.extends: expected a string, got %s", ProfilesKey, chain[0], configTypeName(ext))
+		}
+	}
+	return chain, nil
+}
+
+func reversed(l []string) []string {
+	r := make([]string, len(l))
+	for i, s := range l {
+		r[len(l)-1-i] = s
+	}
+	return r
+}
+
+// Provenance describes where the value at a dotted path came from: its file, followed by the
+// profile when one set it.
+func (t *TomlWrapper) Provenance(key string) string {
+	src := t.Source(key)
+	if p := t.profileOf(key); len(p) > 0 {
+		return src + " (profile " + p + ")"
+	}
+	return src
+}
+
+// profileOf the profile that set the value at a dotted path, empty when a file did
+func (t *TomlWrapper) profileOf(key string) string {
+	keys := strings.Split(stripSelectors(key), ".")
+	for i := len(keys); i > 0; i-- {
+		if p, ok := t.profiles[strings.Join(keys[:i], ".")]; ok {
+			return p
+		}
+	}
+	return ""
+}
+
+// Position returns where the value at a dotted path was written, the file alone when the format
+// does not report lines, and an empty position when no file set it.
+func (t *TomlWrapper) Position(key string) ConfigPosition {
+	key = stripSelectors(key)
+	src := t.Source(key)
+	if len(src) == 0 {
+		// tables hold no value of their own, take the last file that wrote the key
+		for i := len(t.Files) - 1; i >= 0; i-- {
+			if _, ok := t.positions[t.Files[i]][key]; ok {
+				src = t.Files[i]
+				break
+			}
+		}
+	}
+	// a value set by a profile was written in the profile's table
+	if p := t.profileOf(key); len(p) > 0 {
+		key = ProfilesKey + "." + p + "." + key
+	}
+	keys := strings.Split(key, ".")
+	for i := len(keys); i > 0; i-- {
+		if pos, ok := t.positions[src][strings.Join(keys[:i], ".")]; ok {
+			pos.File = src
+			return pos
+		}
+	}
+	return ConfigPosition{File: src}
+}
+
+// mergeConfig copies src over dst, descending into tables both have, and records source for every value set.
+func mergeConfig(dst, src map[string]interface{}, prefix, source string, sources map[string]string) {
+	for k, v := range src {
+		key := joinKey(prefix, k)
+		srcTable, srcIsTable := v.(map[string]interface{})
+		if dstTable, ok := dst[k].(map[string]interface{}); ok && srcIsTable {
+			mergeConfig(dstTable, srcTable, key, source, sources)
+			continue
+		}
+		// a replaced table no longer holds values from earlier files
+		for p := range sources {
+			if strings.HasPrefix(p, key+".") {
+				delete(sources, p)
+			}
+		}
+		if srcIsTable {
+			delete(sources, key)
+			dst[k] = make(map[string]interface{}, len(srcTable))
+			mergeConfig(dst[k].(map[string]interface{}), srcTable, key, source, sources)
+			continue
+		}
+		dst[k] = v
+		sources[key] = source
+	}
+}
+
+var selectors = regexp.MustCompile(`\[[^\]]*\]`)
+
+// stripSelectors drops index and key=value selectors, sources and positions are recorded per array
+func stripSelectors(key string) string {
+	return selectors.ReplaceAllString(key, "")
+}
+
+// joinKey appends k to a dotted path
+func joinKey(prefix, k string) string {
+	if len(prefix) == 0 {
+		return k
+	}
+	return prefix + "." + k
+}
+
 // Has reports whether a dotted path exists in the loaded TOML tree.
 func (t *TomlWrapper) Has(key string) bool {
 	if key == "" {
 		return false
 	}
-	return t.HasPath(strings.Split(key, "."))
-	//if _, ok := t.Map[key]; ok {
-	//	return true
-	//}
-	//return false
+	keys, err := splitPath(key)
+	if err != nil {
+		return false
+	}
+	return t.HasPath(keys)
 }
 
 // HasPath reports whether a nested path exists in the loaded TOML tree.
@@ -58,44 +480,228 @@ func (t *TomlWrapper) HasPath(keys []string) bool {
 	return t.GetPath(keys) != nil
 }
 
-// Get returns the value located at a dotted path.
+// Get returns the value located at a dotted path, nil when there is none. A segment may select an
+// element of an array with an index, clients[0] or clients[-1] for the last, or with a key and value,
+// clients[name=host2]. A segment without a selector reads the last element of an array of tables.
 func (t *TomlWrapper) Get(key string) interface{} {
 	if key == "" {
 		return t
 	}
-	return t.GetPath(strings.Split(key, "."))
+	v, _ := t.Lookup(key)
+	return v
 }
 
-// GetPath walks the nested TOML map and returns the value at the requested path.
+// GetPath walks the nested TOML map and returns the value at the requested path, see Get for selectors.
 func (t *TomlWrapper) GetPath(keys []string) interface{} {
 	if len(keys) == 0 {
 		return t
 	}
-	subtree := t.Map
-	for _, intermediateKey := range keys[:len(keys)-1] {
-		value, exists := subtree[intermediateKey]
-		if !exists {
-			return nil
-		}
-		switch node := value.(type) {
-		case map[string]interface{}:
-			subtree = node
-		case []interface{}:
-			// go to most recent element
-			if len(node) == 0 {
-				return nil
+	v, _ := t.lookup(keys)
+	return v
+}
+
+// Lookup returns the value at a dotted path like Get, with an error naming the part of the path
+// that was not found.
+func (t *TomlWrapper) Lookup(key string) (interface{}, error) {
+	keys, err := splitPath(key)
+	if err != nil {
+		return nil, err
+	}
+	return t.lookup(keys)
+}
+
+func (t *TomlWrapper) lookup(keys []string) (interface{}, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("empty key")
+	}
+	var node interface{} = t.Map
+	for i, k := range keys {
+		path := strings.Join(keys[:i+1], ".")
+		name, selectors, err := parseSegment(k)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		// go to most recent element
+		if arr, ok := node.([]interface{}); ok && len(arr) > 0 {
+			node = arr[len(arr)-1]
+		}
+		subtree, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: %s is %s, not a table", path, strings.Join(keys[:i], "."), configTypeName(node))
+		}
+		node, ok = subtree[name]
+		if !ok {
+			return nil, fmt.Errorf("%s: not found", path)
+		}
+		for _, sel := range selectors {
+			if node, err = selectElement(node, sel); err != nil {
+				return nil, fmt.Errorf("%s: %v", path, err)
+			}
+		}
+	}
+	return node, nil
+}
+
+// splitPath splits a dotted path on the dots outside of selectors, so clients[host=a.b].port is two keys
+func splitPath(key string) ([]string, error) {
+	keys := make([]string, 0, strings.Count(key, ".")+1)
+	depth, start := 0, 0
+	for i, r := range key {
+		switch r {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("%s: unexpected ]", key)
+			}
+		case '.':
+			if depth == 0 {
+				keys = append(keys, key[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("%s: missing ]", key)
+	}
+	return append(keys, key[start:]), nil
+}
+
+// parseSegment splits clients[0][name=a] into the key and its selectors
+func parseSegment(seg string) (string, []string, error) {
+	idx := strings.IndexByte(seg, '[')
+	if idx < 0 {
+		return seg, nil, nil
+	}
+	name, rest := seg[:idx], seg[idx:]
+	var selectors []string
+	for len(rest) > 0 {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 {
+			return "", nil, fmt.Errorf("malformed selector %s", rest)
+		}
+		selectors = append(selectors, rest[1:end])
+		rest = rest[end+1:]
+	}
+	return name, selectors, nil
+}
+
+// selectElement picks an array element by index, negative from the end, or the first table whose field
+// has the value, quotes around the value are optional
+func selectElement(node interface{}, sel string) (interface{}, error) {
+	arr, ok := node.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("[%s] needs an array, found %s", sel, configTypeName(node))
+	}
+	if eq := strings.IndexByte(sel, '='); eq > -1 {
+		field := strings.TrimSpace(sel[:eq])
+		want := strings.Trim(strings.TrimSpace(sel[eq+1:]), `"'`)
+		for _, el := range arr {
+			if tbl, ok := el.(map[string]interface{}); ok {
+				if v, ok := tbl[field]; ok && fmt.Sprint(v) == want {
+					return tbl, nil
+				}
 			}
-			idx := len(node) - 1
-			subtree = node[idx].(map[string]interface{})
-		default:
-			return nil // cannot navigate through other node types
 		}
+		return nil, fmt.Errorf("no element with %s = %s", field, want)
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(sel))
+	if err != nil {
+		return nil, fmt.Errorf("selector [%s] is neither an index nor key=value", sel)
+	}
+	if i < 0 {
+		i += len(arr)
+	}
+	if i < 0 || i >= len(arr) {
+		return nil, fmt.Errorf("index %s out of range, the array has %d elements", sel, len(arr))
+	}
+	return arr[i], nil
+}
+
+// GetString returns the string at a dotted path, an error when it is missing or not a string.
+func (t *TomlWrapper) GetString(key string) (string, error) {
+	v, err := t.Lookup(key)
+	if err != nil {
+		return "", err
+	}
+	s, err := CoerceString(v)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", key, err)
+	}
+	return s, nil
+}
+
+// GetBool returns the boolean at a dotted path, strings such as "true" are converted.
+func (t *TomlWrapper) GetBool(key string) (bool, error) {
+	v, err := t.Lookup(key)
+	if err != nil {
+		return false, err
 	}
-	// branch based on final node type
-	switch node := subtree[keys[len(keys)-1]].(type) {
-	default:
-		return node
+	b, err := CoerceBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s: %v", key, err)
+	}
+	return b, nil
+}
+
+// GetInt64 returns the integer at a dotted path, whole floats and integer strings are converted.
+func (t *TomlWrapper) GetInt64(key string) (int64, error) {
+	v, err := t.Lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	i, err := CoerceInt64(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", key, err)
+	}
+	return i, nil
+}
+
+// GetFloat64 returns the number at a dotted path, integers and numeric strings are converted.
+func (t *TomlWrapper) GetFloat64(key string) (float64, error) {
+	v, err := t.Lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	f, err := CoerceFloat64(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", key, err)
+	}
+	return f, nil
+}
+
+// GetDuration returns the duration at a dotted path, written as a string time.ParseDuration reads,
+// i.e. "1m30s", or as an integer number of seconds.
+func (t *TomlWrapper) GetDuration(key string) (time.Duration, error) {
+	v, err := t.Lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	switch d := v.(type) {
+	case string:
+		dur, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil {
+			return 0, fmt.Errorf("%s: %v", key, err)
+		}
+		return dur, nil
+	case int64:
+		return time.Duration(d) * time.Second, nil
+	}
+	return 0, fmt.Errorf("%s: %v", key, &CoerceError{Want: "a duration", Value: v})
+}
+
+// GetStringSlice returns the array of strings at a dotted path, a comma separated string is split.
+func (t *TomlWrapper) GetStringSlice(key string) ([]string, error) {
+	v, err := t.Lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	l, err := CoerceStringList(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", key, err)
 	}
+	return []string(l), nil
 }
 
 //func LoadToml(path string) (*map[string]interface{}, error) {
@@ -0,0 +1,332 @@
+package mycli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, dir, name, doc string) string {
+	pth := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(pth, []byte(doc), 0644))
+	return pth
+}
+
+func TestTomlWrapperMerge(t *testing.T) {
+	dir := t.TempDir()
+	base := writeConfig(t, dir, "base.toml", "capture = \"hello\"\n[server]\nprotocol = \"http\"\nport = 8080\n[client]\nport = 1\n")
+	over := writeConfig(t, dir, "over.yaml", "server:\n  port: 9090\nclient: false\n")
+
+	var tw TomlWrapper
+	assert.NoError(t, tw.Load(base, ""))
+	assert.NoError(t, tw.Merge(over, ""))
+
+	assert.Equal(t, []string{base, over}, tw.Files)
+	assert.Equal(t, "http", tw.Get("server.protocol"))
+	assert.Equal(t, int64(9090), tw.Get("server.port"))
+	assert.Equal(t, false, tw.Get("client"))
+	assert.Equal(t, base, tw.Source("server.protocol"))
+	assert.Equal(t, over, tw.Source("server.port"))
+	assert.Equal(t, over, tw.Source("client"))
+	assert.Equal(t, "", tw.Source("missing"))
+
+	// Load starts over
+	assert.NoError(t, tw.Load(over, ""))
+	assert.Nil(t, tw.Get("server.protocol"))
+	assert.Equal(t, []string{over}, tw.Files)
+}
+
+func TestLayeredConfigFiles(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	dir := t.TempDir()
+	system := writeConfig(t, dir, "system.toml", "capture = \"system\"\n[server]\nprotocol = \"http\"\nport = 1\n")
+	user := writeConfig(t, dir, "user.toml", "[server]\nport = 2\n")
+	first := writeConfig(t, dir, "first.json", `{"server": {"port": 3}}`)
+	second := writeConfig(t, dir, "second.yaml", "capture: cli\n")
+
+	var (
+		capture, protocol string
+		port              int64
+	)
+	cli = NewCli(nil, nil)
+	cli.TestMode = true
+	cli.ConfigSearchPaths = []string{system, filepath.Join(dir, "missing.toml"), user}
+	cli.Flgs = []CLIFlag{&StringFlg{Variable: &capture, Name: "capture", Value: "hello"}}
+	cli.Cmds = []*CLICommand{
+		{
+			Name:   "server",
+			Action: func() {},
+			Flags: []CLIFlag{
+				&StringFlg{Variable: &protocol, Name: "protocol"},
+				&Int64Flg{Variable: &port, Name: "port"},
+			},
+		},
+	}
+
+	os.Args = []string{"cmd", "-config", first, "-c", second, "server"}
+	assert.NoError(t, cli.Parse())
+	assert.Equal(t, "cli", capture)
+	assert.Equal(t, "http", protocol)
+	assert.Equal(t, int64(3), port)
+	assert.Equal(t, []string{system, user, first, second}, Toml().Files)
+	assert.Equal(t, system, Toml().Source("server.protocol"))
+	assert.Equal(t, first, Toml().Source("server.port"))
+
+	// each -config is one path, a comma stays part of the file name
+	comma := writeConfig(t, dir, "a,b.toml", "capture = \"comma\"\n")
+	ResetForTesting(nil)
+	os.Args = []string{"cmd", "-config", comma}
+	assert.NoError(t, cli.Parse())
+	assert.Equal(t, "comma", capture)
+	assert.Equal(t, []string{system, user, comma}, Toml().Files)
+}
+
+func TestMissingConfigFile(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	missing := filepath.Join(t.TempDir(), "nope.toml")
+	cli = NewCli(nil, nil)
+	cli.TestMode = true
+	cli.MainAction = func() {}
+	os.Args = []string{"cmd", "-config", missing}
+	assert.EqualError(t, cli.Parse(), "config file not found "+missing)
+}
+
+func TestDefaultConfigSearchPaths(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	c := NewCli(nil, nil)
+	name := c.appName()
+	assert.Equal(t, []string{"/etc/" + name + "/config.toml", "/xdg/" + name + "/config.toml", "." + name + ".toml"}, c.DefaultConfigSearchPaths())
+}
+
+func TestDiscoverConfigFile(t *testing.T) {
+	root := t.TempDir()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	c := NewCli(nil, nil)
+	name := c.appName()
+
+	nested := filepath.Join(root, "a", "b")
+	assert.NoError(t, os.MkdirAll(nested, 0755))
+	t.Chdir(nested)
+	assert.Equal(t, "", c.DiscoverConfigFile())
+
+	dotHome := writeConfig(t, home, "."+name, "capture = \"home\"\n")
+	assert.Equal(t, dotHome, c.DiscoverConfigFile())
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(home, ".config", name), 0755))
+	xdg := writeConfig(t, filepath.Join(home, ".config", name), "config.yaml", "capture: xdg\n")
+	assert.Equal(t, xdg, c.DiscoverConfigFile())
+
+	project := writeConfig(t, root, "."+name+".toml", "capture = \"project\"\n")
+	assert.Equal(t, project, c.DiscoverConfigFile())
+}
+
+func TestConfigDiscovery(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	root := t.TempDir()
+	t.Chdir(root)
+	var capture string
+	cli = NewCli(nil, nil)
+	cli.TestMode = true
+	cli.ConfigDiscovery = true
+	cli.MainAction = func() {}
+	cli.Flgs = []CLIFlag{&StringFlg{Variable: &capture, Name: "capture", Value: "hello"}}
+	os.Args = []string{"cmd"}
+	writeConfig(t, root, "."+cli.appName()+".toml", "capture = \"project\"\n")
+	assert.NoError(t, cli.Parse())
+	assert.Equal(t, "project", capture)
+}
+
+func TestNoConfig(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	root := t.TempDir()
+	t.Chdir(root)
+	var capture string
+	cli = NewCli(nil, nil)
+	cli.TestMode = true
+	cli.ConfigDiscovery = true
+	cli.MainAction = func() {}
+	cli.Flgs = []CLIFlag{&StringFlg{Variable: &capture, Name: "capture", Value: "hello"}}
+	os.Args = []string{"cmd", "-no-config"}
+	writeConfig(t, root, "."+cli.appName()+".toml", "capture = \"project\"\n")
+	assert.NoError(t, cli.Parse())
+	assert.Equal(t, "hello", capture)
+}
+
+func TestTomlWrapperSelectors(t *testing.T) {
+	pth := writeConfig(t, t.TempDir(), "clients.toml", `[[clients]]
+name = "host1"
+[clients.connection]
+host = "8.8.8.8"
+port = 22
+
+[[clients]]
+name = "host.two"
+tags = ["a", "b"]
+[clients.connection]
+host = "1.1.1.1"
+port = 2222
+`)
+	var tw TomlWrapper
+	assert.NoError(t, tw.Load(pth, ""))
+
+	assert.Equal(t, "8.8.8.8", tw.Get("clients[0].connection.host"))
+	assert.Equal(t, "1.1.1.1", tw.Get("clients[-1].connection.host"))
+	assert.Equal(t, int64(2222), tw.Get("clients[name=host.two].connection.port"))
+	assert.Equal(t, int64(2222), tw.Get(`clients[name="host.two"].connection.port`))
+	assert.Equal(t, "b", tw.Get("clients[1].tags[1]"))
+	assert.Equal(t, int64(22), tw.GetPath([]string{"clients[name=host1]", "connection", "port"}))
+	// a segment without a selector keeps reading the last element
+	assert.Equal(t, "1.1.1.1", tw.Get("clients.connection.host"))
+	assert.True(t, tw.Has("clients[0].name"))
+	assert.False(t, tw.Has("clients[2].name"))
+	assert.True(t, tw.Has("clients[name=host.two].connection.port"))
+	assert.False(t, tw.Has("clients[0"))
+	assert.Equal(t, pth, tw.Source("clients[0].connection.host"))
+
+	_, err := tw.Lookup("clients[2].name")
+	assert.EqualError(t, err, "clients[2]: index 2 out of range, the array has 2 elements")
+	_, err = tw.Lookup("clients[name=host3]")
+	assert.EqualError(t, err, "clients[name=host3]: no element with name = host3")
+	_, err = tw.Lookup("clients[0].nope.port")
+	assert.EqualError(t, err, "clients[0].nope: not found")
+	_, err = tw.Lookup("clients[0].name.first")
+	assert.EqualError(t, err, "clients[0].name.first: clients[0].name is string, not a table")
+	_, err = tw.Lookup("clients[x]")
+	assert.EqualError(t, err, "clients[x]: selector [x] is neither an index nor key=value")
+	_, err = tw.Lookup("clients[0")
+	assert.EqualError(t, err, "clients[0: missing ]")
+}
+
+func TestTomlWrapperTypedGetters(t *testing.T) {
+	pth := writeConfig(t, t.TempDir(), "typed.yaml", "name: app\nport: \"8080\"\nratio: 1\ndebug: \"true\"\ntimeout: 1m30s\nretry: 5\nhosts: [a, b]\nids: a,b\n")
+	var tw TomlWrapper
+	assert.NoError(t, tw.Load(pth, ""))
+
+	s, err := tw.GetString("name")
+	assert.NoError(t, err)
+	assert.Equal(t, "app", s)
+	i, err := tw.GetInt64("port")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(8080), i)
+	f, err := tw.GetFloat64("ratio")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, f)
+	b, err := tw.GetBool("debug")
+	assert.NoError(t, err)
+	assert.True(t, b)
+	d, err := tw.GetDuration("timeout")
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Second, d)
+	d, err = tw.GetDuration("retry")
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Second, d)
+	l, err := tw.GetStringSlice("hosts")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, l)
+	l, err = tw.GetStringSlice("ids")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, l)
+
+	_, err = tw.GetString("port.x")
+	assert.EqualError(t, err, "port.x: port is string, not a table")
+	_, err = tw.GetString("missing")
+	assert.EqualError(t, err, "missing: not found")
+	_, err = tw.GetInt64("name")
+	assert.EqualError(t, err, `name: expected an integer, got string "app"`)
+	_, err = tw.GetDuration("name")
+	assert.EqualError(t, err, `name: time: invalid duration "app"`)
+	_, err = tw.GetDuration("hosts")
+	assert.EqualError(t, err, "hosts: expected a duration, got array")
+}
+
+func TestTomlWrapperProfiles(t *testing.T) {
+	dir := t.TempDir()
+	base := writeConfig(t, dir, "app.toml", `capture = "hello"
+[server]
+protocol = "http"
+port = 8080
+
+[profiles.base.server]
+protocol = "https"
+
+[profiles.staging]
+extends = "base"
+capture = "staging"
+[profiles.staging.server]
+port = 9090
+
+[profiles.loop]
+extends = "loop2"
+[profiles.loop2]
+extends = "loop"
+`)
+	var tw TomlWrapper
+	assert.NoError(t, tw.Load(base, ""))
+	assert.NoError(t, tw.ApplyProfile("staging"))
+	assert.Equal(t, "staging", tw.Profile)
+	assert.Equal(t, "staging", tw.Get("capture"))
+	assert.Equal(t, "https", tw.Get("server.protocol"))
+	assert.Equal(t, int64(9090), tw.Get("server.port"))
+	assert.Equal(t, base+" (profile staging)", tw.Provenance("server.port"))
+	assert.Equal(t, base+" (profile base)", tw.Provenance("server.protocol"))
+	assert.Equal(t, base, tw.Provenance("profiles.staging.capture"))
+	assert.Equal(t, ConfigPosition{File: base, Line: 13, Column: 1}, tw.Position("server.port"))
+
+	assert.NoError(t, tw.Load(base, ""))
+	assert.EqualError(t, tw.ApplyProfile("prod"), "profile prod not found in "+base)
+	assert.EqualError(t, tw.ApplyProfile("loop"), "profile loop extends itself: loop -> loop2 -> loop")
+	assert.Equal(t, "", tw.Profile)
+	assert.Equal(t, int64(8080), tw.Get("server.port"))
+}
+
+func TestConfigProfileFlag(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	pth := writeConfig(t, t.TempDir(), "app.toml", "capture = \"hello\"\n[profiles.staging]\ncapture = \"staging\"\n[profiles.prod]\ncapture = 1\n")
+	var capture string
+	newCli := func(strict bool) {
+		ResetForTesting(nil)
+		cli = NewCli(nil, nil)
+		cli.TestMode = true
+		cli.DisableEnvVars = false
+		cli.StrictConfig = strict
+		cli.MainAction = func() {}
+		cli.Flgs = []CLIFlag{&StringFlg{Variable: &capture, Name: "capture"}}
+	}
+
+	newCli(false)
+	os.Args = []string{"cmd", "-c", pth, "-profile", "staging"}
+	assert.NoError(t, cli.Parse())
+	assert.Equal(t, "staging", capture)
+
+	newCli(false)
+	os.Setenv("T_PROFILE", "staging")
+	defer os.Unsetenv("T_PROFILE")
+	os.Args = []string{"cmd", "-c", pth}
+	assert.NoError(t, cli.Parse())
+	assert.Equal(t, "staging", capture)
+	assert.Equal(t, "staging", Toml().Profile)
+
+	// strict mode checks every profile, not just the selected one
+	newCli(true)
+	assert.EqualError(t, cli.Parse(), pth+":5:1: profiles.prod.capture: expected a string, got integer 1")
+
+	newCli(false)
+	os.Unsetenv("T_PROFILE")
+	os.Args = []string{"cmd", "-profile", "staging"}
+	assert.EqualError(t, cli.Parse(), "profile staging selected but no config file was loaded")
+}
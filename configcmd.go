@@ -0,0 +1,354 @@
+package mycli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// setupConfigCmd the built-in config command, its subcommands work on the application's config files
+func (c *CLI) setupConfigCmd() *CLICommand {
+	cmd := &CLICommand{
+		Name:   "config",
+		Usage:  "work with the application's config files",
+		Hidden: true,
+		Examples: []Example{
+			{Cmd: c.appName() + " config init " + c.appName() + ".toml", Description: "write a commented config file with every key and its default"},
+			{Cmd: c.appName() + " -config app.toml config show -format env", Description: "print the values a run with app.toml uses as NAME=value lines"},
+			{Cmd: c.appName() + " -config app.toml config validate", Description: "check app.toml and exit non-zero on problems"},
+			{Cmd: c.appName() + " -config app.toml config set server.port 9091", Description: "change one key of app.toml keeping its comments"},
+			{Cmd: c.appName() + " -config-key app.key config encrypt", Description: "encrypt a value read from stdin for an enc: config value"},
+		},
+	}
+	cmd.Action = func() error {
+		return c.showHelp([]string{cmd.Name})
+	}
+	cmd.SubCommands = append(cmd.SubCommands, c.setupConfigInitCmd(), c.setupConfigShowCmd(), c.setupConfigValidateCmd(),
+		c.setupConfigGetCmd(), c.setupConfigSetCmd(), c.setupConfigUnsetCmd(),
+		c.setupConfigEncryptCmd(), c.setupConfigDecryptCmd())
+	return cmd
+}
+
+func (c *CLI) setupConfigInitCmd() *CLICommand {
+	var format string
+	var force bool
+	cmd := &CLICommand{
+		Name:     "init",
+		Usage:    "write a config file with every key, its default and a comment on its use, to stdout or to the file given",
+		Complete: CompleteFiles,
+		Flags: []CLIFlag{
+			&StringFlg{Variable: &format, Name: "format", Usage: "config format, taken from the file extension when not set", Options: configFormats(), EnvVarExclude: true},
+			&BoolFlg{Variable: &force, Name: "force", Usage: "replace an existing file", EnvVarExclude: true},
+		},
+	}
+	cmd.Action = func() error {
+		args := cmd.FS.Args()
+		if len(args) == 0 {
+			if len(format) == 0 {
+				format = "toml"
+			}
+			return c.WriteConfigSkeleton(c.Writer, format)
+		}
+		pth := FixPath(args[0])
+		if err := c.GenerateConfigFile(pth, format, force); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.Writer, "wrote %s\n", pth)
+		return nil
+	}
+	return cmd
+}
+
+func (c *CLI) setupConfigShowCmd() *CLICommand {
+	var format string
+	cmd := &CLICommand{
+		Name:  "show",
+		Usage: "print the value of every flag after command line, env and config are applied, secrets are redacted",
+		Flags: []CLIFlag{
+			&StringFlg{Variable: &format, Name: "format", Usage: "output format", Value: "toml", Options: showFormats(), EnvVarExclude: true},
+		},
+	}
+	cmd.Action = func() error {
+		return c.WriteEffectiveConfig(c.Writer, format)
+	}
+	return cmd
+}
+
+func (c *CLI) setupConfigValidateCmd() *CLICommand {
+	cmd := &CLICommand{
+		Name:     "validate",
+		Usage:    "report unknown keys and values of the wrong type in the config files, or in the files given as arguments",
+		Complete: CompleteFiles,
+	}
+	cmd.Action = func() error {
+		// files given as arguments replace the ones loaded by Parse
+		if args := cmd.FS.Args(); len(args) > 0 {
+			Toml().Reset()
+			for _, a := range args {
+				if err := Toml().Merge(a, configformat); err != nil {
+					return err
+				}
+			}
+		}
+		if len(Toml().Files) == 0 {
+			return fmt.Errorf("no config file to validate, pass -config or a file")
+		}
+		errs := c.ValidateConfig()
+		for _, e := range errs {
+			fmt.Fprintln(c.Writer, e)
+		}
+		files := strings.Join(Toml().Files, ", ")
+		if len(errs) > 0 {
+			return fmt.Errorf("%d problem(s) found in %s", len(errs), files)
+		}
+		fmt.Fprintf(c.Writer, "%s: ok\n", files)
+		return nil
+	}
+	return cmd
+}
+
+// configFileFlag the -file flag of the commands that change one config file
+func configFileFlag(file *string) CLIFlag {
+	return &StringFlg{Variable: file, Name: "file", Usage: "config file to use, the last one loaded when not set", Complete: CompleteFiles, EnvVarExclude: true}
+}
+
+// configTarget the file get, set and unset work on, the one given or the last config file loaded
+func configTarget(file string) (string, error) {
+	if len(file) > 0 {
+		return FixPath(file), nil
+	}
+	if n := len(Toml().Files); n > 0 {
+		return Toml().Files[n-1], nil
+	}
+	return "", fmt.Errorf("no config file loaded, pass -config or -file")
+}
+
+// loadConfigTarget reads a config file into a wrapper of its own, a missing file is empty
+func loadConfigTarget(pth string) (*TomlWrapper, error) {
+	var tw TomlWrapper
+	tw.Reset()
+	if _, err := os.Stat(pth); err == nil {
+		if err = tw.Load(pth, ""); err != nil {
+			return nil, err
+		}
+	}
+	return &tw, nil
+}
+
+// configArgs checks a config subcommand got its positional arguments
+func configArgs(cmd *CLICommand, names ...string) ([]string, error) {
+	args := cmd.FS.Args()
+	if len(args) != len(names) {
+		return nil, fmt.Errorf("config %s expects %d argument(s): <%s>", cmd.Name, len(names), strings.Join(names, "> <"))
+	}
+	return args, nil
+}
+
+func (c *CLI) setupConfigGetCmd() *CLICommand {
+	var file string
+	cmd := &CLICommand{
+		Name:  "get",
+		Usage: "print the value of a key, from the loaded config files or the one given with -file",
+		Flags: []CLIFlag{configFileFlag(&file)},
+	}
+	cmd.Action = func() error {
+		args, err := configArgs(cmd, "key")
+		if err != nil {
+			return err
+		}
+		tw := Toml()
+		if len(file) > 0 {
+			if tw, err = loadConfigTarget(FixPath(file)); err != nil {
+				return err
+			}
+		}
+		v, err := tw.Lookup(args[0])
+		if err != nil {
+			return err
+		}
+		if f := c.configKeyFlag(args[0]); f != nil && isSecret(f) || tw.Encrypted(args[0]) {
+			fmt.Fprintln(c.Writer, Redacted)
+		} else if s, ok := v.(string); ok {
+			fmt.Fprintln(c.Writer, s)
+		} else {
+			fmt.Fprintln(c.Writer, configValue(v))
+		}
+		return nil
+	}
+	return cmd
+}
+
+func (c *CLI) setupConfigSetCmd() *CLICommand {
+	var file string
+	cmd := &CLICommand{
+		Name:  "set",
+		Usage: "write a key to a config file, converted to the type of its flag, comments and key order are kept",
+		Flags: []CLIFlag{configFileFlag(&file)},
+	}
+	cmd.Action = func() error {
+		args, err := configArgs(cmd, "key", "value")
+		if err != nil {
+			return err
+		}
+		f := c.configKeyFlag(args[0])
+		if f == nil {
+			return fmt.Errorf("%s: no flag reads this key", args[0])
+		}
+		v, err := flagConfigValue(f, args[1])
+		if err != nil {
+			return fmt.Errorf("%s: %v", args[0], err)
+		}
+		pth, err := configTarget(file)
+		if err != nil {
+			return err
+		}
+		tw, err := loadConfigTarget(pth)
+		if err != nil {
+			return err
+		}
+		if err = tw.Set(args[0], v); err != nil {
+			return err
+		}
+		if err = tw.Save(pth); err != nil {
+			return err
+		}
+		shown := configValue(v)
+		if isSecret(f) {
+			shown = configValue(Redacted)
+		}
+		fmt.Fprintf(c.Writer, "%s: %s = %s\n", pth, args[0], shown)
+		return nil
+	}
+	return cmd
+}
+
+func (c *CLI) setupConfigUnsetCmd() *CLICommand {
+	var file string
+	cmd := &CLICommand{
+		Name:  "unset",
+		Usage: "remove a key or table from a config file",
+		Flags: []CLIFlag{configFileFlag(&file)},
+	}
+	cmd.Action = func() error {
+		args, err := configArgs(cmd, "key")
+		if err != nil {
+			return err
+		}
+		pth, err := configTarget(file)
+		if err != nil {
+			return err
+		}
+		tw, err := loadConfigTarget(pth)
+		if err != nil {
+			return err
+		}
+		if err = tw.Delete(args[0]); err != nil {
+			return fmt.Errorf("%s: %v", pth, err)
+		}
+		if err = tw.Save(pth); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.Writer, "%s: %s removed\n", pth, args[0])
+		return nil
+	}
+	return cmd
+}
+
+// configKeyFlag the flag of a built-in type that reads a config key, global, command or subcommand
+func (c *CLI) configKeyFlag(key string) CLIFlag {
+	keys := strings.Split(key, ".")
+	flgs, cmds := c.Flgs, c.Cmds
+	for _, k := range keys[:len(keys)-1] {
+		cmd := configCommand(cmds, k)
+		if cmd == nil || cmd.builtin {
+			return nil
+		}
+		flgs, cmds = cmd.Flags, cmd.SubCommands
+	}
+	f := configFlag(flgs, keys[len(keys)-1])
+	if f == nil || skeletonSkipFlags[f.GName()] && len(keys) == 1 || len(skeletonFlags([]CLIFlag{f}, true)) == 0 {
+		return nil
+	}
+	return f
+}
+
+// flagConfigValue converts a value given on the command line to the type its flag reads
+func flagConfigValue(f CLIFlag, s string) (interface{}, error) {
+	switch f.(type) {
+	case *BoolFlg:
+		return CoerceBool(s)
+	case *Int64Flg:
+		return CoerceInt64(s)
+	case *Uint64Flg:
+		return CoerceUint64(s)
+	case *Float64Flg:
+		return CoerceFloat64(s)
+	case *VarFlg:
+		return CoerceStringList(s)
+	}
+	return s, nil
+}
+
+// cryptArgs the key from -config-key and the value to encrypt or decrypt, the argument or stdin
+func (c *CLI) cryptArgs(cmd *CLICommand) ([]byte, string, error) {
+	key, err := c.configKey()
+	if err != nil {
+		return nil, "", err
+	}
+	if key == nil {
+		return nil, "", fmt.Errorf("no key to %s with, pass -config-key", cmd.Name)
+	}
+	args := cmd.FS.Args()
+	switch len(args) {
+	case 0:
+		byt, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, "", err
+		}
+		return key, strings.TrimRight(string(byt), "\r\n"), nil
+	case 1:
+		return key, args[0], nil
+	}
+	return nil, "", fmt.Errorf("config %s expects the value as its argument or on stdin", cmd.Name)
+}
+
+func (c *CLI) setupConfigEncryptCmd() *CLICommand {
+	cmd := &CLICommand{
+		Name:  "encrypt",
+		Usage: "print a value encrypted with the -config-key key as an enc: config value, the value is read from stdin when not given",
+	}
+	cmd.Action = func() error {
+		key, val, err := c.cryptArgs(cmd)
+		if err != nil {
+			return err
+		}
+		enc, err := EncryptConfigValue(key, val)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.Writer, enc)
+		return nil
+	}
+	return cmd
+}
+
+func (c *CLI) setupConfigDecryptCmd() *CLICommand {
+	cmd := &CLICommand{
+		Name:  "decrypt",
+		Usage: "print the plain text of an enc: config value with the -config-key key, the value is read from stdin when not given",
+	}
+	cmd.Action = func() error {
+		key, val, err := c.cryptArgs(cmd)
+		if err != nil {
+			return err
+		}
+		plain, err := DecryptConfigValue(key, val)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.Writer, plain)
+		return nil
+	}
+	return cmd
+}
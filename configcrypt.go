@@ -0,0 +1,145 @@
+package mycli
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// EncryptedPrefix marks a config string holding a value written by EncryptConfigValue
+const EncryptedPrefix = "enc:"
+
+// configKeySize AES-256
+const configKeySize = 32
+
+// ReadConfigKey reads the key encrypted config values use from a file holding 32 bytes, raw or base64 encoded.
+func ReadConfigKey(path string) ([]byte, error) {
+	byt, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(byt) == configKeySize {
+		return byt, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(byt)))
+	if err != nil || len(key) != configKeySize {
+		return nil, fmt.Errorf("config key %s: expected %d bytes, raw or base64 encoded", path, configKeySize)
+	}
+	return key, nil
+}
+
+func configCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptConfigValue encrypts a value with AES-GCM and returns it as enc: followed by the base64
+// encoded nonce and ciphertext, ready to be written to a config file.
+func EncryptConfigValue(key []byte, plain string) (string, error) {
+	aead, err := configCipher(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(plain), nil)), nil
+}
+
+// DecryptConfigValue reverses EncryptConfigValue, the enc: prefix is optional
+func DecryptConfigValue(key []byte, value string) (string, error) {
+	aead, err := configCipher(key)
+	if err != nil {
+		return "", err
+	}
+	byt, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(strings.TrimSpace(value), EncryptedPrefix))
+	if err != nil || len(byt) < aead.NonceSize() {
+		return "", fmt.Errorf("not an encrypted value")
+	}
+	plain, err := aead.Open(nil, byt[:aead.NonceSize()], byt[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("cannot decrypt, wrong key or damaged value")
+	}
+	return string(plain), nil
+}
+
+// configKey the key -config-key names, nil when it is not set
+func (c *CLI) configKey() ([]byte, error) {
+	if len(configkey) == 0 {
+		return nil, nil
+	}
+	return ReadConfigKey(FixPath(ExpandConfigString(configkey)))
+}
+
+// DecryptValues replaces every enc: string of the loaded values, in tables and arrays too, with its
+// plain text. A nil key reports each encrypted value. Problems are returned in key order. See Encrypted
+// for the keys that were decrypted.
+func (t *TomlWrapper) DecryptValues(key []byte) []error {
+	var errs []error
+	if t.encrypted == nil {
+		t.encrypted = make(map[string]bool)
+	}
+	var walk func(v interface{}, path string) interface{}
+	walk = func(v interface{}, path string) interface{} {
+		switch val := v.(type) {
+		case string:
+			if !strings.HasPrefix(val, EncryptedPrefix) {
+				return v
+			}
+			if key == nil {
+				errs = append(errs, &ConfigError{Position: t.Position(path), Key: path, Msg: "encrypted value, pass -config-key to decrypt it"})
+				return v
+			}
+			plain, err := DecryptConfigValue(key, val)
+			if err != nil {
+				errs = append(errs, &ConfigError{Position: t.Position(path), Key: path, Msg: err.Error()})
+				return v
+			}
+			t.encrypted[path] = true
+			return plain
+		case map[string]interface{}:
+			keys := make([]string, 0, len(val))
+			for k := range val {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				val[k] = walk(val[k], joinKey(path, k))
+			}
+		case []interface{}:
+			for i, el := range val {
+				val[i] = walk(el, path)
+			}
+		}
+		return v
+	}
+	walk(t.Map, "")
+	return errs
+}
+
+// Encrypted reports whether the value at a dotted path, or a value inside it, was an enc: string
+// DecryptValues decrypted, such values are redacted wherever the configuration is printed
+func (t *TomlWrapper) Encrypted(key string) bool {
+	key = stripSelectors(key)
+	keys := strings.Split(key, ".")
+	for i := len(keys); i > 0; i-- {
+		if t.encrypted[strings.Join(keys[:i], ".")] {
+			return true
+		}
+	}
+	for k := range t.encrypted {
+		if strings.HasPrefix(k, key+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package mycli
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigValueCrypt(t *testing.T) {
+	dir := t.TempDir()
+	raw := bytes.Repeat([]byte{7}, 32)
+	rawFile := writeConfig(t, dir, "raw.key", string(raw))
+	b64File := writeConfig(t, dir, "b64.key", base64.StdEncoding.EncodeToString(raw)+"\n")
+	badFile := writeConfig(t, dir, "bad.key", "short\n")
+
+	key, err := ReadConfigKey(rawFile)
+	assert.NoError(t, err)
+	assert.Equal(t, raw, key)
+	key, err = ReadConfigKey(b64File)
+	assert.NoError(t, err)
+	assert.Equal(t, raw, key)
+	_, err = ReadConfigKey(badFile)
+	assert.EqualError(t, err, "config key "+badFile+": expected 32 bytes, raw or base64 encoded")
+
+	enc, err := EncryptConfigValue(key, "hunter2")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(enc, EncryptedPrefix))
+	other, _ := EncryptConfigValue(key, "hunter2")
+	assert.NotEqual(t, enc, other)
+	plain, err := DecryptConfigValue(key, enc)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", plain)
+
+	_, err = DecryptConfigValue(bytes.Repeat([]byte{8}, 32), enc)
+	assert.EqualError(t, err, "cannot decrypt, wrong key or damaged value")
+	_, err = DecryptConfigValue(key, "enc:!!")
+	assert.EqualError(t, err, "not an encrypted value")
+}
+
+func TestEncryptedConfigParse(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{7}, 32)
+	keyFile := writeConfig(t, dir, "app.key", string(key))
+	enc, err := EncryptConfigValue(key, "hunter2")
+	assert.NoError(t, err)
+	cfg := writeConfig(t, dir, "app.toml", "capture = \"plain\"\n[server]\ntoken = \""+enc+"\"\n")
+
+	var capture, token string
+	parse := func(args ...string) error {
+		ResetForTesting(nil)
+		cli = NewCli(nil, nil)
+		cli.TestMode = true
+		cli.Flgs = []CLIFlag{&StringFlg{Variable: &capture, Name: "capture"}}
+		cli.Cmds = []*CLICommand{{Name: "server", Action: func() {}, Flags: []CLIFlag{&SecretFlg{Variable: &token, Name: "token"}}}}
+		os.Args = append([]string{"cmd", "-c", cfg}, args...)
+		return cli.Parse()
+	}
+	assert.NoError(t, parse("-config-key", keyFile, "server"))
+	assert.Equal(t, "hunter2", token)
+	assert.Equal(t, "plain", capture)
+
+	assert.EqualError(t, parse("server"), cfg+":3:1: server.token: encrypted value, pass -config-key to decrypt it")
+	wrong := writeConfig(t, dir, "wrong.key", string(bytes.Repeat([]byte{8}, 32)))
+	assert.EqualError(t, parse("-config-key", wrong, "server"), cfg+":3:1: server.token: cannot decrypt, wrong key or damaged value")
+}
+
+func TestConfigEncryptDecryptCmds(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	keyFile := writeConfig(t, t.TempDir(), "app.key", string(bytes.Repeat([]byte{7}, 32)))
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		ResetForTesting(nil)
+		cli = NewCli(nil, nil)
+		cli.TestMode = true
+		cli.Writer = &out
+		os.Args = append([]string{"cmd"}, args...)
+		err := cli.Parse()
+		return out.String(), err
+	}
+	enc, err := run("-config-key", keyFile, "config", "encrypt", "s3cr3t")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(enc, EncryptedPrefix))
+	plain, err := run("-config-key", keyFile, "config", "decrypt", strings.TrimSpace(enc))
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t\n", plain)
+
+	_, err = run("config", "encrypt", "s3cr3t")
+	assert.EqualError(t, err, "no key to encrypt with, pass -config-key")
+	_, err = run("-config-key", keyFile, "config", "decrypt", "a", "b")
+	assert.EqualError(t, err, "config decrypt expects the value as its argument or on stdin")
+}
+
+func TestEncryptedValuesRedacted(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{7}, 32)
+	keyFile := writeConfig(t, dir, "app.key", string(key))
+	enc, err := EncryptConfigValue(key, "hunter2")
+	assert.NoError(t, err)
+	cfg := writeConfig(t, dir, "app.toml", "capture = \"plain\"\n[server]\nhost = \""+enc+"\"\n")
+
+	var capture, host string
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		ResetForTesting(nil)
+		cli = NewCli(nil, nil)
+		cli.TestMode = true
+		cli.Writer = &out
+		cli.Flgs = []CLIFlag{&StringFlg{Variable: &capture, Name: "capture"}}
+		cli.Cmds = []*CLICommand{{Name: "server", Action: func() {}, Flags: []CLIFlag{&StringFlg{Variable: &host, Name: "host", EnvVar: "HOST"}}}}
+		os.Args = append([]string{"cmd", "-c", cfg, "-config-key", keyFile}, args...)
+		err := cli.Parse()
+		return out.String(), err
+	}
+	_, err = run("server")
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", host)
+	assert.True(t, Toml().Encrypted("server.host"))
+	assert.True(t, Toml().Encrypted("server"))
+	assert.False(t, Toml().Encrypted("capture"))
+
+	for _, args := range [][]string{{"config", "get", "server.host"}, {"config", "get", "server"}} {
+		out, err := run(args...)
+		assert.NoError(t, err, args)
+		assert.Equal(t, Redacted+"\n", out, args)
+	}
+	out, err := run("config", "get", "capture")
+	assert.NoError(t, err)
+	assert.Equal(t, "plain\n", out)
+
+	for _, args := range [][]string{{"-print-config"}, {"config", "show", "-format", "env"}} {
+		out, err := run(args...)
+		assert.NoError(t, err, args)
+		assert.Contains(t, out, Redacted, args)
+		assert.NotContains(t, out, "hunter2", args)
+		assert.Contains(t, out, "plain", args)
+	}
+}
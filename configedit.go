@@ -0,0 +1,458 @@
+package mycli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+// configEdit a change made by Set or Delete, replayed on the file text by Save
+type configEdit struct {
+	keys   []string
+	value  interface{}
+	delete bool
+}
+
+// configEditors applies an edit to a config document in each format Save writes
+var configEditors = map[string]func(data []byte, e configEdit) ([]byte, error){
+	"toml": editToml,
+	"yaml": editYaml,
+	"json": editJson,
+}
+
+// tomlEntry a key = value line, offsets into the document, table is the path of the table it is written in
+type tomlEntry struct {
+	path      string
+	table     string
+	lineStart int
+	valStart  int
+	valEnd    int
+	lineEnd   int
+}
+
+// tomlTable a [table] header and the text up to the next header, insertAt is past its last key
+type tomlTable struct {
+	path      string
+	lineStart int
+	insertAt  int
+	end       int
+}
+
+// tomlIndex the entries and tables of a document, tables[0] is the root table
+type tomlIndex struct {
+	entries []tomlEntry
+	tables  []tomlTable
+}
+
+// bareTomlKey keys TOML reads without quotes
+var bareTomlKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func indexToml(data []byte) (*tomlIndex, error) {
+	idx := &tomlIndex{tables: []tomlTable{{insertAt: -1, end: len(data)}}}
+	cur := 0
+	p := unstable.Parser{}
+	p.Reset(data)
+	for p.NextExpression() {
+		e := p.Expression()
+		switch e.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			path, start, end := tomlKeyRange(e, "")
+			ls := lineStart(data, start)
+			idx.tables[cur].end = ls
+			idx.tables = append(idx.tables, tomlTable{path: path, lineStart: ls, insertAt: lineEnd(data, end), end: len(data)})
+			cur = len(idx.tables) - 1
+		case unstable.KeyValue:
+			path, start, end := tomlKeyRange(e, idx.tables[cur].path)
+			vs := end
+			for vs < len(data) && strings.IndexByte(" \t=", data[vs]) > -1 {
+				vs++
+			}
+			ve := scanTomlValue(data, vs)
+			ent := tomlEntry{path: path, table: idx.tables[cur].path, lineStart: lineStart(data, start), valStart: vs, valEnd: ve, lineEnd: lineEnd(data, ve)}
+			idx.entries = append(idx.entries, ent)
+			idx.tables[cur].insertAt = ent.lineEnd
+		}
+	}
+	if err := p.Error(); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// tomlKeyRange the dotted path of a key under prefix, and where the key starts and ends
+func tomlKeyRange(n *unstable.Node, prefix string) (string, int, int) {
+	path, start, end := prefix, -1, 0
+	it := n.Key()
+	for it.Next() {
+		k := it.Node()
+		path = joinKey(path, string(k.Data))
+		if start < 0 {
+			start = int(k.Raw.Offset)
+		}
+		end = int(k.Raw.Offset + k.Raw.Length)
+	}
+	return path, start, end
+}
+
+func lineStart(data []byte, i int) int {
+	return bytes.LastIndexByte(data[:i], '\n') + 1
+}
+
+// lineEnd the offset past the newline ending the line holding i
+func lineEnd(data []byte, i int) int {
+	if j := bytes.IndexByte(data[i:], '\n'); j > -1 {
+		return i + j + 1
+	}
+	return len(data)
+}
+
+// scanTomlValue the offset past the value starting at i, without a trailing comment
+func scanTomlValue(data []byte, i int) int {
+	if i >= len(data) {
+		return i
+	}
+	switch {
+	case bytes.HasPrefix(data[i:], []byte(`"""`)):
+		return closeTomlString(data, i+3, `"""`, true)
+	case bytes.HasPrefix(data[i:], []byte(`'''`)):
+		return closeTomlString(data, i+3, `'''`, false)
+	case data[i] == '"':
+		return closeTomlString(data, i+1, `"`, true)
+	case data[i] == '\'':
+		return closeTomlString(data, i+1, `'`, false)
+	case data[i] == '[' || data[i] == '{':
+		depth := 0
+		for j := i; j < len(data); {
+			switch data[j] {
+			case '[', '{':
+				depth++
+			case ']', '}':
+				depth--
+				if depth == 0 {
+					return j + 1
+				}
+			case '"', '\'':
+				j = scanTomlValue(data, j)
+				continue
+			case '#':
+				j = lineEnd(data, j)
+				continue
+			}
+			j++
+		}
+		return len(data)
+	}
+	end := i
+	for end < len(data) && data[end] != '\n' && data[end] != '#' {
+		end++
+	}
+	for end > i && strings.IndexByte(" \t\r", data[end-1]) > -1 {
+		end--
+	}
+	return end
+}
+
+// closeTomlString the offset past the delimiter closing a string, a multiline string may end in up to two more quotes
+func closeTomlString(data []byte, j int, delim string, escapes bool) int {
+	for j < len(data) {
+		if escapes && data[j] == '\\' {
+			j += 2
+			continue
+		}
+		if bytes.HasPrefix(data[j:], []byte(delim)) {
+			end := j + len(delim)
+			for k := 0; len(delim) == 3 && k < 2 && end < len(data) && data[end] == delim[0]; k++ {
+				end++
+			}
+			return end
+		}
+		j++
+	}
+	return len(data)
+}
+
+// tomlKey quotes a key segment TOML does not read bare
+func tomlKey(k string) string {
+	if bareTomlKey.MatchString(k) {
+		return k
+	}
+	return configValue(k)
+}
+
+func tomlKeys(keys []string) string {
+	q := make([]string, len(keys))
+	for i, k := range keys {
+		q[i] = tomlKey(k)
+	}
+	return strings.Join(q, ".")
+}
+
+// editToml changes the text of one entry, inserts a line into the closest table or removes lines, the rest
+// of the document is kept byte for byte
+func editToml(data []byte, e configEdit) ([]byte, error) {
+	idx, err := indexToml(data)
+	if err != nil {
+		return nil, err
+	}
+	path := strings.Join(e.keys, ".")
+	var ent *tomlEntry
+	for i := range idx.entries {
+		if idx.entries[i].path == path {
+			ent = &idx.entries[i]
+		} else if strings.HasPrefix(path, idx.entries[i].path+".") {
+			return nil, fmt.Errorf("%s is written inline by %s, edit the file by hand", path, idx.entries[i].path)
+		}
+	}
+	if e.delete {
+		if ent != nil {
+			return splice(data, ent.lineStart, ent.lineEnd, ""), nil
+		}
+		// a table goes with its subtables and the keys written dotted into other tables, a.b = 1
+		var cuts [][2]int
+		for _, t := range idx.tables[1:] {
+			if t.path == path || strings.HasPrefix(t.path, path+".") {
+				cuts = append(cuts, [2]int{t.lineStart, t.end})
+			}
+		}
+		for _, en := range idx.entries {
+			if strings.HasPrefix(en.path, path+".") {
+				cuts = append(cuts, [2]int{en.lineStart, en.lineEnd})
+			}
+		}
+		if len(cuts) == 0 {
+			return nil, fmt.Errorf("%s: not found", path)
+		}
+		// keys inside a table that is cut go with it
+		sort.Slice(cuts, func(i, j int) bool { return cuts[i][0] < cuts[j][0] })
+		merged := cuts[:1]
+		for _, c := range cuts[1:] {
+			if last := &merged[len(merged)-1]; c[0] < last[1] {
+				if c[1] > last[1] {
+					last[1] = c[1]
+				}
+				continue
+			}
+			merged = append(merged, c)
+		}
+		// last first so offsets stay valid
+		for i := len(merged) - 1; i >= 0; i-- {
+			data = splice(data, merged[i][0], merged[i][1], "")
+		}
+		return data, nil
+	}
+	val := configValue(e.value)
+	if ent != nil {
+		return splice(data, ent.valStart, ent.valEnd, val), nil
+	}
+	// the table with the longest path holding the key, the last one for arrays of tables
+	tbl := -1
+	for i := len(idx.tables) - 1; i > 0; i-- {
+		t := idx.tables[i]
+		if strings.HasPrefix(path, t.path+".") && (tbl < 0 || len(t.path) > len(idx.tables[tbl].path)) {
+			tbl = i
+		}
+	}
+	// a table written as dotted keys of the root table, a.b = 1, gets its new keys the same way
+	dotted := false
+	for _, en := range idx.entries {
+		if len(en.table) == 0 && strings.HasPrefix(en.path, e.keys[0]+".") {
+			dotted = true
+		}
+	}
+	switch {
+	case tbl < 0 && len(e.keys) > 1 && dotted:
+		return insertLine(data, idx.tables[0].insertAt, tomlKeys(e.keys)+" = "+val+"\n"), nil
+	case tbl > 0:
+		t := idx.tables[tbl]
+		rest := tomlKeys(e.keys[strings.Count(t.path, ".")+1:])
+		return insertLine(data, t.insertAt, rest+" = "+val+"\n"), nil
+	case len(e.keys) == 1 && idx.tables[0].insertAt > -1:
+		return insertLine(data, idx.tables[0].insertAt, tomlKey(e.keys[0])+" = "+val+"\n"), nil
+	case len(e.keys) == 1 && len(idx.tables) > 1:
+		return splice(data, idx.tables[1].lineStart, idx.tables[1].lineStart, tomlKey(e.keys[0])+" = "+val+"\n\n"), nil
+	case len(e.keys) == 1:
+		return insertLine(data, len(data), tomlKey(e.keys[0])+" = "+val+"\n"), nil
+	}
+	last := len(e.keys) - 1
+	line := "[" + tomlKeys(e.keys[:last]) + "]\n" + tomlKey(e.keys[last]) + " = " + val + "\n"
+	if len(bytes.TrimSpace(data)) > 0 {
+		line = "\n" + line
+	}
+	return insertLine(data, len(data), line), nil
+}
+
+func splice(data []byte, start, end int, s string) []byte {
+	out := make([]byte, 0, len(data)-(end-start)+len(s))
+	out = append(out, data[:start]...)
+	out = append(out, s...)
+	return append(out, data[end:]...)
+}
+
+// insertLine inserts a line at i, first ending the line before when the file does not end in a newline
+func insertLine(data []byte, i int, line string) []byte {
+	if i > 0 && data[i-1] != '\n' {
+		line = "\n" + line
+	}
+	return splice(data, i, i, line)
+}
+
+// yamlDocument parses YAML or JSON keeping comments and order, an empty document is an empty mapping
+func yamlDocument(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("the document is not a table")
+	}
+	return &doc, nil
+}
+
+// editYamlNode applies an edit to a mapping, the comments of a replaced value move to the new one
+func editYamlNode(m *yaml.Node, e configEdit) error {
+	last := len(e.keys) - 1
+	for i, k := range e.keys[:last] {
+		child := yamlMappingValue(m, k)
+		if child == nil {
+			if e.delete {
+				return fmt.Errorf("%s: not found", strings.Join(e.keys[:i+1], "."))
+			}
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, child)
+		}
+		if child.Kind == yaml.SequenceNode && len(child.Content) > 0 {
+			child = child.Content[len(child.Content)-1]
+		}
+		if child.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s is not a table", strings.Join(e.keys[:i+1], "."))
+		}
+		m = child
+	}
+	pos := -1
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == e.keys[last] {
+			pos = i
+		}
+	}
+	if e.delete {
+		if pos < 0 {
+			return fmt.Errorf("%s: not found", strings.Join(e.keys, "."))
+		}
+		m.Content = append(m.Content[:pos], m.Content[pos+2:]...)
+		return nil
+	}
+	var n yaml.Node
+	if err := n.Encode(e.value); err != nil {
+		return err
+	}
+	if _, ok := e.value.(float64); ok {
+		// keep floats floats, yaml writes 2.0 as 2
+		n.Value, n.Tag = configValue(e.value), "!!float"
+	}
+	if pos < 0 {
+		m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: e.keys[last]}, &n)
+		return nil
+	}
+	old := m.Content[pos+1]
+	n.HeadComment, n.LineComment, n.FootComment = old.HeadComment, old.LineComment, old.FootComment
+	m.Content[pos+1] = &n
+	return nil
+}
+
+func yamlMappingValue(m *yaml.Node, k string) *yaml.Node {
+	var v *yaml.Node
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == k {
+			v = m.Content[i+1]
+		}
+	}
+	return v
+}
+
+func editYaml(data []byte, e configEdit) ([]byte, error) {
+	doc, err := yamlDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	if err = editYamlNode(doc.Content[0], e); err != nil {
+		return nil, err
+	}
+	var byt bytes.Buffer
+	enc := yaml.NewEncoder(&byt)
+	enc.SetIndent(2)
+	if err = enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err = enc.Close(); err != nil {
+		return nil, err
+	}
+	return byt.Bytes(), nil
+}
+
+// editJson edits the document as YAML, which keeps the key order, and writes it back as indented JSON
+func editJson(data []byte, e configEdit) ([]byte, error) {
+	doc, err := yamlDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	if err = editYamlNode(doc.Content[0], e); err != nil {
+		return nil, err
+	}
+	var byt bytes.Buffer
+	if err = writeJsonNode(&byt, doc.Content[0], ""); err != nil {
+		return nil, err
+	}
+	byt.WriteString("\n")
+	return byt.Bytes(), nil
+}
+
+func writeJsonNode(w *bytes.Buffer, n *yaml.Node, indent string) error {
+	switch n.Kind {
+	case yaml.AliasNode:
+		return writeJsonNode(w, n.Alias, indent)
+	case yaml.MappingNode, yaml.SequenceNode:
+		open, end, step := "{", "}", 2
+		if n.Kind == yaml.SequenceNode {
+			open, end, step = "[", "]", 1
+		}
+		if len(n.Content) == 0 {
+			w.WriteString(open + end)
+			return nil
+		}
+		w.WriteString(open)
+		sep := "\n"
+		for i := 0; i < len(n.Content); i += step {
+			w.WriteString(sep + indent + "  ")
+			if step == 2 {
+				name, _ := json.Marshal(n.Content[i].Value)
+				w.Write(name)
+				w.WriteString(": ")
+			}
+			if err := writeJsonNode(w, n.Content[i+step-1], indent+"  "); err != nil {
+				return err
+			}
+			sep = ",\n"
+		}
+		w.WriteString("\n" + indent + end)
+		return nil
+	}
+	if n.ShortTag() == "!!str" {
+		w.WriteString(configValue(n.Value))
+		return nil
+	}
+	var v interface{}
+	if err := n.Decode(&v); err != nil {
+		return err
+	}
+	w.WriteString(configValue(v))
+	return nil
+}
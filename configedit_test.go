@@ -0,0 +1,208 @@
+package mycli
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readFile(t *testing.T, pth string) string {
+	byt, err := os.ReadFile(pth)
+	assert.NoError(t, err)
+	return string(byt)
+}
+
+func TestTomlWrapperSaveToml(t *testing.T) {
+	pth := writeConfig(t, t.TempDir(), "app.toml", `# app settings
+capture = "hello" # greeting
+hosts = [
+  "a", # first
+  "b",
+]
+
+# the server
+[server]
+port = 8080 # listen port
+notes = """
+multi
+line"""
+
+[server.tls]
+cert = 'c.pem'
+`)
+	var tw TomlWrapper
+	assert.NoError(t, tw.Load(pth, ""))
+	assert.NoError(t, tw.Set("server.port", 9091))
+	assert.NoError(t, tw.Set("server.notes", "one"))
+	assert.NoError(t, tw.Set("hosts", []string{"x"}))
+	assert.NoError(t, tw.Set("ratio", 0.5))
+	assert.NoError(t, tw.Set("server.protocol", "https"))
+	assert.NoError(t, tw.Set("update.dry run", true))
+	assert.NoError(t, tw.Delete("server.tls"))
+	assert.NoError(t, tw.Save(pth))
+	assert.Equal(t, `# app settings
+capture = "hello" # greeting
+hosts = ["x"]
+ratio = 0.5
+
+# the server
+[server]
+port = 9091 # listen port
+notes = "one"
+protocol = "https"
+
+[update]
+"dry run" = true
+`, readFile(t, pth))
+	assert.Equal(t, int64(9091), tw.Get("server.port"))
+	assert.Nil(t, tw.Get("server.tls"))
+
+	assert.NoError(t, tw.Load(pth, ""))
+	assert.Equal(t, true, tw.Get(`update.dry run`))
+	assert.NoError(t, tw.Delete("capture"))
+	assert.NoError(t, tw.Save(pth))
+	assert.NotContains(t, readFile(t, pth), "capture")
+}
+
+func TestTomlWrapperSaveDottedKeys(t *testing.T) {
+	pth := writeConfig(t, t.TempDir(), "app.toml", `name = "x"
+server.port = 8080 # listen port
+server.tls.cert = "c.pem"
+log.level = "info"
+
+[client]
+retry.count = 3
+retry.wait = 1
+timeout = 5
+`)
+	var tw TomlWrapper
+	assert.NoError(t, tw.Load(pth, ""))
+	assert.NoError(t, tw.Delete("server"))
+	assert.NoError(t, tw.Delete("client.retry"))
+	assert.NoError(t, tw.Set("log.format", "json"))
+	assert.NoError(t, tw.Set("client.retry.count", 4))
+	assert.NoError(t, tw.Save(pth))
+	assert.Equal(t, `name = "x"
+log.level = "info"
+log.format = "json"
+
+[client]
+timeout = 5
+retry.count = 4
+`, readFile(t, pth))
+
+	assert.NoError(t, tw.Load(pth, ""))
+	assert.Equal(t, "json", tw.Get("log.format"))
+	assert.Equal(t, int64(4), tw.Get("client.retry.count"))
+	assert.Nil(t, tw.Get("server"))
+}
+
+func TestTomlWrapperSaveYamlJson(t *testing.T) {
+	dir := t.TempDir()
+	pth := writeConfig(t, dir, "app.yaml", "# app settings\ncapture: hello # greeting\nserver:\n  # listen port\n  port: 8080\n")
+	var tw TomlWrapper
+	assert.NoError(t, tw.Load(pth, ""))
+	assert.NoError(t, tw.Set("server.port", 9091))
+	assert.NoError(t, tw.Set("update.hosts", []string{"a", "b"}))
+	assert.NoError(t, tw.Save(pth))
+	assert.Equal(t, `# app settings
+capture: hello # greeting
+server:
+  # listen port
+  port: 9091
+update:
+  hosts:
+    - a
+    - b
+`, readFile(t, pth))
+
+	pth = writeConfig(t, dir, "app.json", `{"zeta": 1, "alpha": {"port": 8080, "on": false}}`)
+	tw = TomlWrapper{}
+	assert.NoError(t, tw.Load(pth, ""))
+	assert.NoError(t, tw.Set("alpha.port", 9091))
+	assert.NoError(t, tw.Delete("alpha.on"))
+	assert.NoError(t, tw.Set("ratio", 2.0))
+	assert.NoError(t, tw.Save(pth))
+	assert.Equal(t, `{
+  "zeta": 1,
+  "alpha": {
+    "port": 9091
+  },
+  "ratio": 2.0
+}
+`, readFile(t, pth))
+}
+
+func TestTomlWrapperSetErrors(t *testing.T) {
+	var tw TomlWrapper
+	tw.Reset()
+	assert.NoError(t, tw.Set("server.port", 1))
+	assert.EqualError(t, tw.Set("server", 1), "server is a table, set its keys instead")
+	assert.EqualError(t, tw.Set("server.port.x", 1), "server.port is integer, not a table")
+	assert.EqualError(t, tw.Set("hosts[0]", 1), "hosts[0]: expected a dotted path without selectors")
+	assert.EqualError(t, tw.Set("m", map[string]interface{}{}), "m: cannot set table, only scalars and arrays")
+	assert.EqualError(t, tw.Delete("server.host"), "server.host: not found")
+
+	pth := writeConfig(t, t.TempDir(), "app.toml", "server = {port = 1}\n")
+	assert.NoError(t, tw.Load(pth, ""))
+	assert.NoError(t, tw.Set("server.port", 2))
+	assert.EqualError(t, tw.Save(pth), "issue saving toml config file "+pth+"\nserver.port is written inline by server, edit the file by hand")
+}
+
+func TestConfigSetGetUnsetCmds(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	pth := writeConfig(t, t.TempDir(), "app.toml", "# settings\ncapture = \"hello\"\n\n[server]\nprotocol = \"http\" # scheme\n")
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		ResetForTesting(nil)
+		cli = skeletonTestCli()
+		cli.Writer = &out
+		os.Args = append([]string{"cmd", "-c", pth, "config"}, args...)
+		err := cli.Parse()
+		return out.String(), err
+	}
+	out, err := run("set", "server.protocol", "https")
+	assert.NoError(t, err)
+	assert.Equal(t, pth+": server.protocol = \"https\"\n", out)
+	_, err = run("set", "ratio", "0.25")
+	assert.NoError(t, err)
+	_, err = run("set", "hosts", "a,b")
+	assert.NoError(t, err)
+	assert.Equal(t, "# settings\ncapture = \"hello\"\nratio = 0.25\nhosts = [\"a\",\"b\"]\n\n[server]\nprotocol = \"https\" # scheme\n", readFile(t, pth))
+
+	out, err = run("get", "server.protocol")
+	assert.NoError(t, err)
+	assert.Equal(t, "https\n", out)
+	out, err = run("get", "hosts")
+	assert.NoError(t, err)
+	assert.Equal(t, "[\"a\",\"b\"]\n", out)
+	// a key named after an application command is an argument, not the command
+	out, err = run("get", "server")
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"protocol\":\"https\"}\n", out)
+
+	out, err = run("unset", "ratio")
+	assert.NoError(t, err)
+	assert.Equal(t, pth+": ratio removed\n", out)
+	assert.NotContains(t, readFile(t, pth), "ratio")
+
+	_, err = run("set", "ratio", "many")
+	assert.EqualError(t, err, `ratio: expected a float, got string "many"`)
+	_, err = run("set", "server.nope", "1")
+	assert.EqualError(t, err, "server.nope: no flag reads this key")
+	_, err = run("set", "ratio")
+	assert.EqualError(t, err, "config set expects 2 argument(s): <key> <value>")
+	_, err = run("unset", "ratio")
+	assert.EqualError(t, err, pth+": ratio: not found")
+
+	out, err = run("unset", "server")
+	assert.NoError(t, err)
+	assert.Equal(t, pth+": server removed\n", out)
+	assert.NotContains(t, readFile(t, pth), "server")
+	_, err = run("set", "server", "1")
+	assert.EqualError(t, err, "server: no flag reads this key")
+}
@@ -0,0 +1,93 @@
+package mycli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IncludeKey the root key of a config file listing the files merged before it
+const IncludeKey = "include"
+
+// configVar matches ${VAR}, ${VAR:-default} and the $${ escape
+var configVar = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// ExpandConfigString expands ${VAR} to the value LookupEnv finds, empty when unset, and
+// ${VAR:-default} to default when VAR is unset or empty. A leading ~ is then replaced with the home
+// directory. $${ writes a literal ${.
+func ExpandConfigString(s string) string {
+	if strings.Contains(s, "${") {
+		s = configVar.ReplaceAllStringFunc(s, func(m string) string {
+			if m == "$${" {
+				return "${"
+			}
+			sub := configVar.FindStringSubmatch(m)
+			if v, _ := LookupEnv(sub[1]); len(v) > 0 || len(sub[2]) == 0 {
+				return v
+			}
+			return sub[3]
+		})
+	}
+	if s == "~" || strings.HasPrefix(s, "~/") || strings.HasPrefix(s, "~"+string(filepath.Separator)) {
+		if home, err := os.UserHomeDir(); err == nil {
+			s = home + s[1:]
+		}
+	}
+	return s
+}
+
+// expandConfigMap expands every string in a loaded document, in arrays and tables too
+func expandConfigMap(m map[string]interface{}) {
+	for k, v := range m {
+		m[k] = expandConfigValue(v)
+	}
+}
+
+func expandConfigValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return ExpandConfigString(val)
+	case map[string]interface{}:
+		expandConfigMap(val)
+	case []interface{}:
+		for i, el := range val {
+			val[i] = expandConfigValue(el)
+		}
+	}
+	return v
+}
+
+// configIncludes removes the include key from a loaded document and returns its files, relative paths
+// resolved against the directory of the including file
+func configIncludes(m map[string]interface{}, path string) ([]string, error) {
+	v, ok := m[IncludeKey]
+	if !ok {
+		return nil, nil
+	}
+	delete(m, IncludeKey)
+	var files []string
+	switch val := v.(type) {
+	case string:
+		files = []string{val}
+	case []interface{}:
+		for _, el := range val {
+			s, ok := el.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: expected an array of files, got %s in it", IncludeKey, configTypeName(el))
+			}
+			files = append(files, s)
+		}
+	default:
+		return nil, fmt.Errorf("%s: expected a file or an array of files, got %s", IncludeKey, configTypeName(v))
+	}
+	for i, f := range files {
+		f = ExpandConfigString(f)
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(filepath.Dir(path), f)
+		}
+		files[i] = f
+	}
+	return files, nil
+}
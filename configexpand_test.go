@@ -0,0 +1,68 @@
+package mycli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandConfigString(t *testing.T) {
+	os.Setenv("T_EXPAND_HOST", "db1")
+	os.Setenv("T_EXPAND_EMPTY", "")
+	defer os.Unsetenv("T_EXPAND_HOST")
+	defer os.Unsetenv("T_EXPAND_EMPTY")
+	home, err := os.UserHomeDir()
+	assert.NoError(t, err)
+
+	tests := []struct{ in, want string }{
+		{"${T_EXPAND_HOST}:5432", "db1:5432"},
+		{"${T_EXPAND_MISSING}", ""},
+		{"${T_EXPAND_MISSING:-localhost}", "localhost"},
+		{"${T_EXPAND_EMPTY:-localhost}", "localhost"},
+		{"${T_EXPAND_HOST:-localhost}", "db1"},
+		{"$${T_EXPAND_HOST}", "${T_EXPAND_HOST}"},
+		{"pa$$word ${ not a var", "pa$$word ${ not a var"},
+		{"~/data", filepath.Join(home, "data")},
+		{"~", home},
+		{"a~/b", "a~/b"},
+		{"${T_EXPAND_MISSING:-~/cache}", filepath.Join(home, "cache")},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, ExpandConfigString(test.in), test.in)
+	}
+}
+
+func TestConfigIncludes(t *testing.T) {
+	os.Setenv("T_EXPAND_PORT", "9090")
+	defer os.Unsetenv("T_EXPAND_PORT")
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "common"), 0755))
+	common := writeConfig(t, dir, "common/base.toml", "capture = \"base\"\n[server]\nprotocol = \"http\"\nport = 8080\n")
+	shared := writeConfig(t, dir, "common/shared.yaml", "include: base.toml\nhosts: [\"${T_EXPAND_MISSING:-a}\", b]\n")
+	app := writeConfig(t, dir, "app.toml", "include = [\"common/shared.yaml\"]\ncapture = \"app\"\n[server]\nport = \"${T_EXPAND_PORT}\"\n")
+
+	var tw TomlWrapper
+	assert.NoError(t, tw.Load(app, ""))
+	assert.Equal(t, []string{common, shared, app}, tw.Files)
+	assert.Equal(t, "app", tw.Get("capture"))
+	assert.Equal(t, "http", tw.Get("server.protocol"))
+	assert.Equal(t, "9090", tw.Get("server.port"))
+	assert.Equal(t, []interface{}{"a", "b"}, tw.Get("hosts"))
+	assert.Nil(t, tw.Get(IncludeKey))
+	assert.Equal(t, common, tw.Source("server.protocol"))
+	assert.Equal(t, ConfigPosition{File: common, Line: 3, Column: 1}, tw.Position("server.protocol"))
+	port, err := tw.GetInt64("server.port")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9090), port)
+
+	a := writeConfig(t, dir, "a.toml", "include = \"b.toml\"\n")
+	b := writeConfig(t, dir, "b.toml", "include = [\"a.toml\"]\n")
+	assert.EqualError(t, tw.Load(a, ""), "include cycle: "+a+" -> "+b+" -> "+a)
+
+	bad := writeConfig(t, dir, "bad.toml", "include = 1\n")
+	assert.EqualError(t, tw.Load(bad, ""), bad+": include: expected a file or an array of files, got integer")
+	missing := writeConfig(t, dir, "missing.toml", "include = \"nope.toml\"\n")
+	assert.EqualError(t, tw.Load(missing, ""), missing+": include "+filepath.Join(dir, "nope.toml")+": stat "+filepath.Join(dir, "nope.toml")+": no such file or directory")
+}
@@ -0,0 +1,124 @@
+package mycli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// configFile a config file to merge and the format to read it with, empty to use its extension
+type configFile struct {
+	path       string
+	format     string
+	discovered bool
+}
+
+// DefaultConfigSearchPaths returns the system, user and project config files of the application in merge
+// order: /etc/<app>/config.toml, $XDG_CONFIG_HOME/<app>/config.toml and ./.<app>.toml.
+func (c *CLI) DefaultConfigSearchPaths() []string {
+	name := c.appName()
+	paths := []string{filepath.Join(string(filepath.Separator)+"etc", name, "config.toml")}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(configHome(home), name, "config.toml"))
+	}
+	return append(paths, "."+name+".toml")
+}
+
+// DiscoverConfigFile returns the first config file found by searching the current directory and its
+// parents for .<app>.toml like git does, then $XDG_CONFIG_HOME/<app>/config.toml, then $HOME/.<app>
+// which may be a file or a directory holding config.toml. Other registered formats are tried after
+// .toml, i.e. .<app>.yaml. Empty when nothing is found.
+func (c *CLI) DiscoverConfigFile() string {
+	name := c.appName()
+	if dir, err := os.Getwd(); err == nil {
+		for {
+			if p := findConfig(filepath.Join(dir, "."+name)); len(p) > 0 {
+				return p
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	if p := findConfig(filepath.Join(configHome(home), name, "config")); len(p) > 0 {
+		return p
+	}
+	dot := filepath.Join(home, "."+name)
+	if fi, err := os.Stat(dot); err == nil {
+		if !fi.IsDir() {
+			return dot
+		}
+		return findConfig(filepath.Join(dot, "config"))
+	}
+	return findConfig(dot)
+}
+
+// findConfig returns base with the first config extension that exists, .toml first
+func findConfig(base string) string {
+	exts := make([]string, 0, len(ConfigExtensions))
+	for ext := range ConfigExtensions {
+		if ext != ".toml" {
+			exts = append(exts, ext)
+		}
+	}
+	sort.Strings(exts)
+	for _, ext := range append([]string{".toml"}, exts...) {
+		if fi, err := os.Stat(base + ext); err == nil && !fi.IsDir() {
+			return base + ext
+		}
+	}
+	return ""
+}
+
+// configFiles lists the files to merge: existing ConfigSearchPaths, then every -config file in the
+// order given, or with ConfigDiscovery and no -config the discovered file. A -config file that does
+// not exist is an error, search paths are optional. -no-config skips everything but -config files.
+func (c *CLI) configFiles() ([]configFile, error) {
+	files := make([]configFile, 0)
+	if !noconfig {
+		for _, p := range c.ConfigSearchPaths {
+			p = FixPath(p)
+			if _, err := os.Stat(p); err == nil {
+				files = append(files, configFile{path: p})
+			}
+		}
+	}
+	explicit := false
+	for _, p := range configfiles {
+		if len(strings.TrimSpace(p)) == 0 {
+			continue
+		}
+		p = FixPath(p)
+		if _, err := os.Stat(p); err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("config file not found %v", p)
+			}
+			return nil, err
+		}
+		files = append(files, configFile{path: p, format: configformat})
+		explicit = true
+	}
+	if c.ConfigDiscovery && !explicit && !noconfig {
+		if p := c.DiscoverConfigFile(); len(p) > 0 && !hasConfigFile(files, p) {
+			files = append(files, configFile{path: p, discovered: true})
+		}
+	}
+	return files, nil
+}
+
+func hasConfigFile(files []configFile, pth string) bool {
+	for _, f := range files {
+		if f.path == pth {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,275 @@
+package mycli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigLoader decodes a config document into the nested map TomlWrapper walks. Tables are
+// map[string]interface{}, arrays []interface{}, integers int64 and floats float64 whatever the format,
+// integers above math.MaxInt64 are uint64.
+type ConfigLoader func(data []byte) (map[string]interface{}, error)
+
+// ConfigLoaders config formats by name, add an entry to support another format
+var ConfigLoaders = map[string]ConfigLoader{
+	"toml": loadTomlConfig,
+	"yaml": loadYamlConfig,
+	"json": loadJsonConfig,
+}
+
+// ConfigExtensions maps file extensions to the format in ConfigLoaders used to read them
+var ConfigExtensions = map[string]string{
+	".toml": "toml",
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".json": "json",
+}
+
+// ConfigFormat returns the format of path from its extension, toml when the extension is not known.
+func ConfigFormat(path string) string {
+	if format, ok := ConfigExtensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return format
+	}
+	return "toml"
+}
+
+// configFormats lists the registered formats in a stable order
+func configFormats() []string {
+	formats := make([]string, 0, len(ConfigLoaders))
+	for k := range ConfigLoaders {
+		formats = append(formats, k)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+func loadTomlConfig(data []byte) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	if err := toml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func loadYamlConfig(data []byte) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return normalizeConfigMap(m)
+}
+
+func loadJsonConfig(data []byte) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// keep integers exact instead of decoding every number as float64
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	return normalizeConfigMap(m)
+}
+
+func normalizeConfigMap(m map[string]interface{}) (map[string]interface{}, error) {
+	for k, v := range m {
+		nv, err := normalizeConfigValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", k, err)
+		}
+		m[k] = nv
+	}
+	return m, nil
+}
+
+// normalizeConfigValue converts decoded values to the types go-toml produces so flags read every format alike.
+func normalizeConfigValue(v interface{}) (interface{}, error) {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		return normalizeConfigMap(node)
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(node))
+		for k, val := range node {
+			m[fmt.Sprint(k)] = val
+		}
+		return normalizeConfigMap(m)
+	case []interface{}:
+		for i, val := range node {
+			nv, err := normalizeConfigValue(val)
+			if err != nil {
+				return nil, err
+			}
+			node[i] = nv
+		}
+		return node, nil
+	case json.Number:
+		if i, err := node.Int64(); err == nil {
+			return i, nil
+		}
+		// too large for int64, Uint64Flg reads it and the Coerce functions report it out of range
+		if u, err := strconv.ParseUint(node.String(), 10, 64); err == nil {
+			return u, nil
+		}
+		return node.Float64()
+	case int:
+		return int64(node), nil
+	case int32:
+		return int64(node), nil
+	case uint64:
+		if node <= math.MaxInt64 {
+			return int64(node), nil
+		}
+		return node, nil
+	case float32:
+		return float64(node), nil
+	}
+	return v, nil
+}
+
+// ConfigPosition where a key is written in a config file, Line and Column start at 1 and are 0 when unknown
+type ConfigPosition struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (p ConfigPosition) String() string {
+	if p.Line == 0 {
+		return p.File
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// configLocators find the position of every key of a document by dotted path, values inside arrays
+// are recorded without an index like GetPath reads them, the last element wins
+var configLocators = map[string]func(data []byte) map[string]ConfigPosition{
+	"toml": locateToml,
+	"yaml": locateYaml,
+	"json": locateJson,
+}
+
+func locateToml(data []byte) map[string]ConfigPosition {
+	positions := make(map[string]ConfigPosition)
+	p := unstable.Parser{}
+	p.Reset(data)
+	table := ""
+	for p.NextExpression() {
+		e := p.Expression()
+		switch e.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			table = locateTomlKey(&p, e, "", positions)
+		case unstable.KeyValue:
+			locateTomlKeyValue(&p, e, table, positions)
+		}
+	}
+	return positions
+}
+
+// locateTomlKey records every part of a dotted key and returns the full path
+func locateTomlKey(p *unstable.Parser, n *unstable.Node, prefix string, positions map[string]ConfigPosition) string {
+	path := prefix
+	it := n.Key()
+	for it.Next() {
+		k := it.Node()
+		path = joinKey(path, string(k.Data))
+		if _, ok := positions[path]; !ok || it.IsLast() {
+			s := p.Shape(k.Raw)
+			positions[path] = ConfigPosition{Line: s.Start.Line, Column: s.Start.Column}
+		}
+	}
+	return path
+}
+
+func locateTomlKeyValue(p *unstable.Parser, n *unstable.Node, table string, positions map[string]ConfigPosition) {
+	path := locateTomlKey(p, n, table, positions)
+	if v := n.Value(); v.Kind == unstable.InlineTable {
+		it := v.Children()
+		for it.Next() {
+			locateTomlKeyValue(p, it.Node(), path, positions)
+		}
+	}
+}
+
+func locateYaml(data []byte) map[string]ConfigPosition {
+	positions := make(map[string]ConfigPosition)
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return positions
+	}
+	locateYamlNode(doc.Content[0], "", positions)
+	return positions
+}
+
+func locateYamlNode(n *yaml.Node, prefix string, positions map[string]ConfigPosition) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k := n.Content[i]
+			path := joinKey(prefix, k.Value)
+			positions[path] = ConfigPosition{Line: k.Line, Column: k.Column}
+			locateYamlNode(n.Content[i+1], path, positions)
+		}
+	case yaml.SequenceNode:
+		for _, el := range n.Content {
+			locateYamlNode(el, prefix, positions)
+		}
+	}
+}
+
+func locateJson(data []byte) map[string]ConfigPosition {
+	positions := make(map[string]ConfigPosition)
+	locateJsonValue(json.NewDecoder(bytes.NewReader(data)), data, "", positions)
+	return positions
+}
+
+// locateJsonValue reads one value and records the keys of the objects in it below prefix
+func locateJsonValue(dec *json.Decoder, data []byte, prefix string, positions map[string]ConfigPosition) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		for dec.More() {
+			start := dec.InputOffset()
+			key, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			path := joinKey(prefix, fmt.Sprint(key))
+			positions[path] = jsonPosition(data, int(start))
+			if err = locateJsonValue(dec, data, path, positions); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+	case json.Delim('['):
+		for dec.More() {
+			if err = locateJsonValue(dec, data, prefix, positions); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+	}
+	return err
+}
+
+// jsonPosition line and column of the first token at or after offset, the decoder reports the
+// offset before the separator in front of a key
+func jsonPosition(data []byte, offset int) ConfigPosition {
+	for offset < len(data) && strings.ContainsRune(" \t\r\n,:", rune(data[offset])) {
+		offset++
+	}
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	col := offset - bytes.LastIndexByte(data[:offset], '\n')
+	return ConfigPosition{Line: line, Column: col}
+}
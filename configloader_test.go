@@ -0,0 +1,130 @@
+package mycli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	loaderToml = `
+name = "app"
+ratio = 1.5
+[server]
+port = 9090
+tags = ["a", "b"]
+[[clients]]
+name = "host1"
+[[clients]]
+name = "host2"
+`
+	loaderYaml = `
+name: app
+ratio: 1.5
+server:
+  port: 9090
+  tags: [a, b]
+clients:
+  - name: host1
+  - name: host2
+`
+	loaderJson = `{"name": "app", "ratio": 1.5, "server": {"port": 9090, "tags": ["a", "b"]},
+"clients": [{"name": "host1"}, {"name": "host2"}]}`
+)
+
+func TestConfigLoaders(t *testing.T) {
+	want, err := ConfigLoaders["toml"]([]byte(loaderToml))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9090), want["server"].(map[string]interface{})["port"])
+
+	for format, doc := range map[string]string{"yaml": loaderYaml, "json": loaderJson} {
+		t.Run(format, func(t *testing.T) {
+			got, err := ConfigLoaders[format]([]byte(doc))
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestConfigLoadersLargeIntegers(t *testing.T) {
+	for format, doc := range map[string]string{
+		"yaml": "big: 18446744073709551615\nmax: 9223372036854775807\n",
+		"json": `{"big": 18446744073709551615, "max": 9223372036854775807}`,
+	} {
+		t.Run(format, func(t *testing.T) {
+			got, err := ConfigLoaders[format]([]byte(doc))
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(18446744073709551615), got["big"])
+			assert.Equal(t, int64(9223372036854775807), got["max"])
+
+			_, err = CoerceInt64(got["big"])
+			assert.EqualError(t, err, "expected an integer, got integer 18446744073709551615 (out of range)")
+			u, err := CoerceUint64(got["big"])
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(18446744073709551615), u)
+		})
+	}
+}
+
+func TestConfigFormat(t *testing.T) {
+	assert.Equal(t, "yaml", ConfigFormat("/etc/app/config.YML"))
+	assert.Equal(t, "json", ConfigFormat("config.json"))
+	assert.Equal(t, "toml", ConfigFormat("config"))
+
+	var tw TomlWrapper
+	dir := t.TempDir()
+	pth := filepath.Join(dir, "app.conf")
+	assert.NoError(t, os.WriteFile(pth, []byte(loaderJson), 0644))
+	assert.Error(t, tw.Load(pth, ""))
+	assert.NoError(t, tw.Load(pth, "json"))
+	assert.Equal(t, "host2", tw.Get("clients.name"))
+	assert.Error(t, tw.Load(pth, "ini"))
+}
+
+func TestParseYamlConfig(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	type hidden struct {
+		Hideme struct {
+			Myprotocol string `json:"myprotocol"`
+		} `json:"hideme"`
+	}
+	var (
+		capture string
+		port    int64
+		app     string
+		hid     hidden
+	)
+	dir := t.TempDir()
+	pth := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, os.WriteFile(pth, []byte("capture: bye\nserver:\n  port: 9090\n  status:\n    app: gc\nhideme:\n  myprotocol: https\n"), 0644))
+
+	cli = NewCli(nil, nil)
+	cli.TestMode = true
+	cli.Flgs = []CLIFlag{
+		&StringFlg{Variable: &capture, Name: "capture", Value: "hello"},
+	}
+	cli.Cmds = []*CLICommand{
+		{
+			Name:   "server",
+			Action: func() {},
+			Flags: []CLIFlag{
+				&Int64Flg{Variable: &port, Name: "port", Value: 8080},
+			},
+			SubCommands: []*CLICommand{
+				{Name: "status", Action: func() {}, Flags: []CLIFlag{&StringFlg{Variable: &app, Name: "app"}}},
+			},
+		},
+		{Name: "hideme", Hidden: true, Variable: &hid},
+	}
+
+	os.Args = []string{"cmd", "-c", pth, "server", "status"}
+	assert.NoError(t, cli.Parse())
+	assert.Equal(t, "bye", capture)
+	assert.Equal(t, int64(9090), port)
+	assert.Equal(t, "gc", app)
+	assert.Equal(t, "https", hid.Hideme.Myprotocol)
+}
@@ -0,0 +1,138 @@
+package mycli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// SecretNames matches flag names whose values are redacted when the configuration is printed, a word must
+// be a whole segment of the name between -, _ or . so tokenizer or secretariat-url are not matched
+var SecretNames = regexp.MustCompile(`(?i)(^|[-_.])(passw(or)?d|secrets?|tokens?|api[-_]?key|private[-_]?key|credentials?)([-_.]|$)`)
+
+// Redacted replaces secret values in printed configuration
+const Redacted = "<redacted>"
+
+// showFormats formats WriteEffectiveConfig writes, the config formats plus env
+func showFormats() []string {
+	return append(configFormats(), "env")
+}
+
+// WriteEffectiveConfig writes the value every flag holds once command line, env, config and defaults are
+// applied, in the layout of a config file so the output can be passed back with -config. Format is a config
+// format or env for NAME=value lines. Values of flags matching SecretNames, and values the config held
+// encrypted, are replaced with Redacted.
+func (c *CLI) WriteEffectiveConfig(w io.Writer, format string) error {
+	var byt bytes.Buffer
+	if p := Toml().Profile; len(p) > 0 && format != "json" {
+		byt.WriteString("# profile: " + p + "\n")
+	}
+	if format == "env" {
+		c.prepare()
+		c.writeEffectiveEnv(&byt, c.skeleton(true))
+	} else {
+		write, ok := configDocWriters[format]
+		if !ok {
+			return fmt.Errorf("unsupported format '%s', supported formats are %v", format, showFormats())
+		}
+		c.prepare()
+		root := c.skeleton(true)
+		paths := skeletonPaths(root)
+		write(&byt, &configDoc{root: root, value: func(f CLIFlag) string {
+			if redacted(f, paths[f]) {
+				return configValue(Redacted)
+			}
+			return configValue(flagValue(f))
+		}})
+	}
+	_, err := w.Write(byt.Bytes())
+	return err
+}
+
+// writeEffectiveEnv writes NAME=value lines, flags without an env var are listed as comments
+func (c *CLI) writeEffectiveEnv(w *bytes.Buffer, root *skeletonTable) {
+	if c.DisableEnvVars {
+		w.WriteString("# env lookup is off, set DisableEnvVars = false to read these\n")
+	}
+	var write func(t *skeletonTable, prefix string)
+	write = func(t *skeletonTable, prefix string) {
+		for _, f := range t.flags {
+			name := f.GEnvVar()
+			if !c.envVarsSet {
+				name = c.buildEnvVar(f)
+			}
+			val := envValue(flagValue(f))
+			if redacted(f, joinKey(prefix, f.GName())) {
+				val = Redacted
+			}
+			if f.GEnvVarExclude() || len(name) == 0 {
+				w.WriteString("# " + joinKey(prefix, f.GName()) + " has no env var\n")
+				continue
+			}
+			w.WriteString(name + "=" + dotenvQuote(val) + "\n")
+		}
+		for _, st := range t.subs {
+			write(st, joinKey(prefix, st.name))
+		}
+	}
+	write(root, "")
+}
+
+// skeletonPaths the config key of each flag of a skeleton
+func skeletonPaths(root *skeletonTable) map[CLIFlag]string {
+	paths := make(map[CLIFlag]string)
+	var walk func(t *skeletonTable, prefix string)
+	walk = func(t *skeletonTable, prefix string) {
+		for _, f := range t.flags {
+			paths[f] = joinKey(prefix, f.GName())
+		}
+		for _, st := range t.subs {
+			walk(st, joinKey(prefix, st.name))
+		}
+	}
+	walk(root, "")
+	return paths
+}
+
+// flagValue the value the flag's variable holds
+func flagValue(f CLIFlag) interface{} {
+	rv := reflect.ValueOf(f.GVariable())
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return f.GValue()
+	}
+	return rv.Elem().Interface()
+}
+
+// isSecret reports whether a flag's value is hidden from printed configuration
+func isSecret(f CLIFlag) bool {
+	if _, ok := f.(*SecretFlg); ok {
+		return true
+	}
+	return SecretNames.MatchString(f.GName())
+}
+
+// redacted reports whether a flag's value is hidden from printed configuration, because the flag is secret
+// or the loaded config held its key encrypted
+func redacted(f CLIFlag, flagPath string) bool {
+	return isSecret(f) || Toml().Encrypted(flagPath)
+}
+
+// envValue a value as it is written on the command line, lists comma separated
+func envValue(v interface{}) string {
+	if l, ok := v.(StringList); ok {
+		return strings.Join(l, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// dotenvQuote double quotes values a dotenv file would otherwise read differently
+func dotenvQuote(s string) string {
+	if len(s) > 0 && !strings.ContainsAny(s, " \t\r\n\"'\\#$`=") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`, "`", "\\`")
+	return `"` + r.Replace(s) + `"`
+}
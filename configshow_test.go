@@ -0,0 +1,138 @@
+package mycli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func showTestCli() *CLI {
+	var (
+		capture, password, protocol string
+		port                        int64
+		hosts                       StringList
+	)
+	c := NewCli(nil, nil)
+	c.TestMode = true
+	c.DisableEnvVars = false
+	c.Flgs = []CLIFlag{
+		&StringFlg{Variable: &capture, Name: "capture", Value: "hello"},
+		&StringFlg{Variable: &password, Name: "db-password", Hidden: true},
+		&VarFlg{Variable: &hosts, Name: "hosts"},
+	}
+	c.Cmds = []*CLICommand{
+		{
+			Name:   "server",
+			Action: func() error { return nil },
+			Flags: []CLIFlag{
+				&StringFlg{Variable: &protocol, Name: "protocol", Value: "http", EnvVarExclude: true},
+				&Int64Flg{Variable: &port, Name: "port", Value: 8080},
+			},
+		},
+	}
+	return c
+}
+
+// fromKey drops the built-in debug and proxy keys every dump starts with
+func fromKey(s, key string) string {
+	if idx := strings.Index(s, key); idx > -1 {
+		return s[idx:]
+	}
+	return s
+}
+
+func TestPrintConfig(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	pth := writeConfig(t, t.TempDir(), "app.toml", "db-password = \"hunter2\"\n[server]\nport = 9090\n")
+	os.Setenv("T_HOSTS", "a,b")
+	defer os.Unsetenv("T_HOSTS")
+	var out bytes.Buffer
+	ran := false
+	cli = showTestCli()
+	cli.Writer = &out
+	cli.Cmds[0].Action = func() error { ran = true; return nil }
+	os.Args = []string{"cmd", "-c", pth, "-capture", "bye", "-print-config", "server", "-protocol", "https"}
+	assert.NoError(t, cli.Parse())
+	assert.False(t, ran)
+	assert.Equal(t, `capture = "bye"
+db-password = "<redacted>"
+hosts = ["a","b"]
+
+[server]
+protocol = "https"
+port = 9090
+`, fromKey(out.String(), "capture"))
+}
+
+func TestSecretNames(t *testing.T) {
+	for _, name := range []string{"password", "db-password", "db_passwd", "api_key", "apikey", "api-key", "private-key", "token", "auth.token", "client-secret", "secrets", "credentials"} {
+		assert.True(t, SecretNames.MatchString(name), name)
+	}
+	for _, name := range []string{"tokenizer", "secretariat-url", "keyring", "passwordless-login-url"} {
+		assert.False(t, SecretNames.MatchString(name), name)
+	}
+}
+
+func TestConfigShowCmd(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	pth := writeConfig(t, t.TempDir(), "app.yaml", "capture: \"it's here\"\nserver:\n  port: 9090\n")
+	var out bytes.Buffer
+	cli = showTestCli()
+	cli.Writer = &out
+	os.Args = []string{"cmd", "-c", pth, "config", "show", "-format", "env"}
+	assert.NoError(t, cli.Parse())
+	assert.Equal(t, `T_CAPTURE="it's here"
+T_DB-PASSWORD=<redacted>
+T_HOSTS=""
+# server.protocol has no env var
+T_PORT=9090
+`, fromKey(out.String(), "T_CAPTURE"))
+}
+
+func TestEffectiveConfigReplay(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	dir := t.TempDir()
+	pth := writeConfig(t, dir, "app.json", `{"capture": "bye", "hosts": ["x"], "server": {"port": 1}}`)
+	cli = showTestCli()
+	cli.MainAction = func() {}
+	os.Args = []string{"cmd", "-c", pth}
+	assert.NoError(t, cli.Parse())
+
+	for _, format := range []string{"toml", "yaml", "json"} {
+		var out bytes.Buffer
+		assert.NoError(t, cli.WriteEffectiveConfig(&out, format))
+		dump := filepath.Join(dir, "dump."+format)
+		assert.NoError(t, os.WriteFile(dump, out.Bytes(), 0644))
+		var tw TomlWrapper
+		assert.NoError(t, tw.Load(dump, ""), format)
+		assert.Equal(t, "bye", tw.Get("capture"), format)
+		assert.Equal(t, []interface{}{"x"}, tw.Get("hosts"), format)
+		assert.Equal(t, int64(1), tw.Get("server.port"), format)
+	}
+	assert.EqualError(t, cli.WriteEffectiveConfig(&bytes.Buffer{}, "ini"), "unsupported format 'ini', supported formats are [json toml yaml env]")
+}
+
+func TestPrintConfigProfile(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	pth := writeConfig(t, t.TempDir(), "app.toml", "capture = \"x\"\n[profiles.staging.server]\nport = 9090\n")
+	var out bytes.Buffer
+	cli = showTestCli()
+	cli.Writer = &out
+	os.Args = []string{"cmd", "-c", pth, "-profile", "staging", "-print-config", "server"}
+	assert.NoError(t, cli.Parse())
+	assert.True(t, strings.HasPrefix(out.String(), "# profile: staging\n"))
+	assert.Contains(t, out.String(), "[server]\nprotocol = \"http\"\nport = 9090\n")
+	assert.NotContains(t, out.String(), "profiles")
+}
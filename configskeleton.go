@@ -0,0 +1,250 @@
+package mycli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// skeletonSkipFlags built-in global flags that select or describe config files, or only make sense on the command line
+var skeletonSkipFlags = map[string]bool{
+	"help":                     true,
+	"help-all":                 true,
+	"version":                  true,
+	"config":                   true,
+	"config-format":            true,
+	"no-config":                true,
+	"generate-bash-completion": true,
+	"print-config":             true,
+	"profile":                  true,
+	"config-key":               true,
+	"env-file":                 true,
+}
+
+// configDocWriters writes a config document in each supported format
+var configDocWriters = map[string]func(w *bytes.Buffer, d *configDoc){
+	"toml": writeTomlDoc,
+	"yaml": writeYamlDoc,
+	"json": writeJsonDoc,
+}
+
+// configDoc a config file to write, the tables of keys, how each value is written, and whether it is
+// a skeleton whose keys carry comments
+type configDoc struct {
+	root     *skeletonTable
+	value    func(f CLIFlag) string
+	skeleton bool
+}
+
+// skeletonTable the keys parseConfigFile reads for one command, the root holds the global flags
+type skeletonTable struct {
+	name  string
+	flags []CLIFlag
+	subs  []*skeletonTable
+}
+
+// WriteConfigSkeleton writes a config file holding every key Parse reads with its default value. In TOML
+// and YAML each key is preceded by its usage, options and env var, and required keys are left commented
+// out. JSON has no comments and lists the keys and defaults only.
+func (c *CLI) WriteConfigSkeleton(w io.Writer, format string) error {
+	write, ok := configDocWriters[format]
+	if !ok {
+		return fmt.Errorf("unsupported config format '%s', supported formats are %v", format, configFormats())
+	}
+	c.prepare()
+	var byt bytes.Buffer
+	write(&byt, &configDoc{root: c.skeleton(false), value: func(f CLIFlag) string {
+		if _, ok := f.(*SecretFlg); ok {
+			return configValue("")
+		}
+		return configValue(f.GValue())
+	}, skeleton: true})
+	_, err := w.Write(byt.Bytes())
+	return err
+}
+
+// GenerateConfigFile writes WriteConfigSkeleton to path, an empty format is taken from the extension.
+// An existing file is only replaced with overwrite.
+func (c *CLI) GenerateConfigFile(path, format string, overwrite bool) error {
+	if len(format) == 0 {
+		format = ConfigFormat(path)
+	}
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return fmt.Errorf("config file %s already exists", path)
+	}
+	var byt bytes.Buffer
+	if err := c.WriteConfigSkeleton(&byt, format); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, byt.Bytes(), 0644)
+}
+
+// skeleton collects the global flags and the command and subcommand tables parseConfigFile reads,
+// hidden flags are included on request
+func (c *CLI) skeleton(hidden bool) *skeletonTable {
+	root := &skeletonTable{}
+	for _, f := range skeletonFlags(c.Flgs, hidden) {
+		if !skeletonSkipFlags[f.GName()] {
+			root.flags = append(root.flags, f)
+		}
+	}
+	for _, cmd := range c.Cmds {
+		if cmd.Hidden || cmd.builtin {
+			continue
+		}
+		t := &skeletonTable{name: cmd.Name, flags: skeletonFlags(cmd.Flags, hidden)}
+		for _, sub := range cmd.SubCommands {
+			if flgs := skeletonFlags(sub.Flags, hidden); !sub.Hidden && len(flgs) > 0 {
+				t.subs = append(t.subs, &skeletonTable{name: sub.Name, flags: flgs})
+			}
+		}
+		if len(t.flags) > 0 || len(t.subs) > 0 {
+			root.subs = append(root.subs, t)
+		}
+	}
+	return root
+}
+
+// skeletonFlags flags of the built-in types, custom types read structures a default cannot show
+func skeletonFlags(flgs []CLIFlag, hidden bool) []CLIFlag {
+	tmp := make([]CLIFlag, 0, len(flgs))
+	for _, f := range flgs {
+		if f.GHidden() && !hidden {
+			continue
+		}
+		switch f.(type) {
+		case *BoolFlg, *Int64Flg, *Uint64Flg, *Float64Flg, *StringFlg, *VarFlg, *SecretFlg:
+			tmp = append(tmp, f)
+		}
+	}
+	return tmp
+}
+
+// configValue a flag value written so TOML, YAML and JSON all read it back with its type
+func configValue(v interface{}) string {
+	if l, ok := v.(StringList); ok && len(l) == 0 {
+		return "[]"
+	}
+	var byt bytes.Buffer
+	enc := json.NewEncoder(&byt)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return `""`
+	}
+	s := strings.TrimSuffix(byt.String(), "\n")
+	// keep floats floats, json writes 2.0 as 2
+	if _, ok := v.(float64); ok && !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
+
+// writeSkeletonComment writes the usage, options, env var and required marker of a flag as comments
+func writeSkeletonComment(w *bytes.Buffer, indent string, f CLIFlag) {
+	for _, l := range strings.Split(strings.TrimSpace(f.GUsage()), "\n") {
+		if len(l) > 0 {
+			w.WriteString(indent + "# " + l + "\n")
+		}
+	}
+	if opts := optionValues(f.GOptions()); len(opts) > 0 {
+		w.WriteString(indent + "# options: " + strings.Join(opts, ", ") + "\n")
+	}
+	if env := f.GEnvVar(); len(env) > 0 {
+		w.WriteString(indent + "# env: " + env + "\n")
+	}
+	if f.GRequired() {
+		w.WriteString(indent + "# required, set a value other than the default\n")
+	}
+}
+
+// key writes the name of a flag, a skeleton comments out required keys so the default does not look like
+// a chosen value
+func (d *configDoc) key(f CLIFlag, name string) string {
+	if d.skeleton && f.GRequired() {
+		return "# " + name
+	}
+	return name
+}
+
+func writeTomlDoc(w *bytes.Buffer, d *configDoc) {
+	writeTomlKeys(w, d, d.root.flags)
+	for _, t := range d.root.subs {
+		if len(t.flags) > 0 {
+			w.WriteString("\n[" + tomlKey(t.name) + "]\n")
+			writeTomlKeys(w, d, t.flags)
+		}
+		for _, st := range t.subs {
+			w.WriteString("\n[" + tomlKeys([]string{t.name, st.name}) + "]\n")
+			writeTomlKeys(w, d, st.flags)
+		}
+	}
+}
+
+func writeTomlKeys(w *bytes.Buffer, d *configDoc, flgs []CLIFlag) {
+	for i, f := range flgs {
+		if d.skeleton {
+			if i > 0 {
+				w.WriteString("\n")
+			}
+			writeSkeletonComment(w, "", f)
+		}
+		w.WriteString(d.key(f, tomlKey(f.GName())) + " = " + d.value(f) + "\n")
+	}
+}
+
+func writeYamlDoc(w *bytes.Buffer, d *configDoc) {
+	writeYamlKeys(w, d, "", d.root.flags)
+	for _, t := range d.root.subs {
+		w.WriteString("\n" + t.name + ":\n")
+		writeYamlKeys(w, d, "  ", t.flags)
+		for _, st := range t.subs {
+			if d.skeleton || len(t.flags) > 0 {
+				w.WriteString("\n")
+			}
+			w.WriteString("  " + st.name + ":\n")
+			writeYamlKeys(w, d, "    ", st.flags)
+		}
+	}
+}
+
+func writeYamlKeys(w *bytes.Buffer, d *configDoc, indent string, flgs []CLIFlag) {
+	for i, f := range flgs {
+		if d.skeleton {
+			if i > 0 {
+				w.WriteString("\n")
+			}
+			writeSkeletonComment(w, indent, f)
+		}
+		w.WriteString(indent + d.key(f, f.GName()) + ": " + d.value(f) + "\n")
+	}
+}
+
+func writeJsonDoc(w *bytes.Buffer, d *configDoc) {
+	writeJsonTable(w, d, "", d.root.flags, d.root.subs)
+	w.WriteString("\n")
+}
+
+// writeJsonTable writes an object by hand so keys keep the order of the flags
+func writeJsonTable(w *bytes.Buffer, d *configDoc, indent string, flgs []CLIFlag, subs []*skeletonTable) {
+	w.WriteString("{")
+	sep := "\n"
+	for _, f := range flgs {
+		name, _ := json.Marshal(f.GName())
+		w.WriteString(sep + indent + "  " + string(name) + ": " + d.value(f))
+		sep = ",\n"
+	}
+	for _, t := range subs {
+		name, _ := json.Marshal(t.name)
+		w.WriteString(sep + indent + "  " + string(name) + ": ")
+		writeJsonTable(w, d, indent+"  ", t.flags, t.subs)
+		sep = ",\n"
+	}
+	w.WriteString("\n" + indent + "}")
+}
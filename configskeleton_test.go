@@ -0,0 +1,144 @@
+package mycli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func skeletonTestCli() *CLI {
+	var (
+		capture, protocol, app string
+		port                   int64
+		ratio                  float64
+		hosts                  StringList
+	)
+	c := NewCli(nil, nil)
+	c.TestMode = true
+	c.DisableEnvVars = false
+	c.Flgs = []CLIFlag{
+		&StringFlg{Variable: &capture, Name: "capture", Usage: "text to capture", Value: "hello", Options: []string{"hello", "bye"}},
+		&Float64Flg{Variable: &ratio, Name: "ratio", Usage: "sample ratio", Value: 2},
+		&VarFlg{Variable: &hosts, Name: "hosts", Usage: "hosts to call", EnvVarExclude: true},
+	}
+	c.Cmds = []*CLICommand{
+		{
+			Name:  "server",
+			Flags: []CLIFlag{&StringFlg{Variable: &protocol, Name: "protocol", Usage: "protocol", Value: "http", EnvVarExclude: true}},
+			SubCommands: []*CLICommand{
+				{Name: "app", Flags: []CLIFlag{&StringFlg{Variable: &app, Name: "name", Usage: "application", Required: true, EnvVarExclude: true}}},
+			},
+		},
+		{Name: "update", Flags: []CLIFlag{&Int64Flg{Variable: &port, Name: "port", Hidden: true}}},
+	}
+	return c
+}
+
+func TestWriteConfigSkeleton(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, skeletonTestCli().WriteConfigSkeleton(&out, "toml"))
+	assert.Equal(t, `# flag set to debug
+debug = false
+
+# set debug level
+# env: T_DEBUG_LEVEL
+debugLevel = 0
+
+# Sets http_proxy for network connections
+# env: T_HTTP_PROXY
+proxyhttp = ""
+
+# Sets https_proxy for network connections
+# env: T_HTTPS_PROXY
+proxyhttps = ""
+
+# Sets no_proxy for network connections
+# env: T_NO_PROXY
+noproxy = ""
+
+# text to capture
+# options: hello, bye
+# env: T_CAPTURE
+capture = "hello"
+
+# sample ratio
+# env: T_RATIO
+ratio = 2.0
+
+# hosts to call
+hosts = []
+
+[server]
+# protocol
+protocol = "http"
+
+[server.app]
+# application
+# required, set a value other than the default
+# name = ""
+`, out.String())
+
+	out.Reset()
+	assert.EqualError(t, skeletonTestCli().WriteConfigSkeleton(&out, "ini"), "unsupported config format 'ini', supported formats are [json toml yaml]")
+}
+
+func TestConfigSkeletonFormats(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	dir := t.TempDir()
+	for _, name := range []string{"app.toml", "app.yaml", "app.json"} {
+		pth := filepath.Join(dir, name)
+		c := skeletonTestCli()
+		assert.NoError(t, c.GenerateConfigFile(pth, "", false))
+		assert.EqualError(t, c.GenerateConfigFile(pth, "", false), "config file "+pth+" already exists")
+		assert.NoError(t, Toml().Load(pth, ""))
+		assert.Empty(t, c.ValidateConfig(), name)
+		assert.Equal(t, "hello", Toml().Get("capture"), name)
+		assert.Equal(t, 2.0, Toml().Get("ratio"), name)
+		assert.Equal(t, "http", Toml().Get("server.protocol"), name)
+	}
+}
+
+func TestConfigSkeletonQuotedKeys(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	var port, level int64
+	c := NewCli(nil, nil)
+	c.TestMode = true
+	c.Cmds = []*CLICommand{
+		{
+			Name:        "web:api",
+			Flags:       []CLIFlag{&Int64Flg{Variable: &port, Name: "ns:port"}},
+			SubCommands: []*CLICommand{{Name: "v 2", Flags: []CLIFlag{&Int64Flg{Variable: &level, Name: "level"}}}},
+		},
+	}
+	var out bytes.Buffer
+	assert.NoError(t, c.WriteConfigSkeleton(&out, "toml"))
+	assert.Contains(t, out.String(), "\n[\"web:api\"]\n\"ns:port\" = 0\n")
+	assert.Contains(t, out.String(), "\n[\"web:api\".\"v 2\"]\nlevel = 0\n")
+
+	pth := filepath.Join(t.TempDir(), "app.toml")
+	assert.NoError(t, os.WriteFile(pth, out.Bytes(), 0644))
+	assert.NoError(t, Toml().Load(pth, ""))
+}
+
+func TestConfigInitCmd(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	pth := filepath.Join(t.TempDir(), "app.yaml")
+	var out bytes.Buffer
+	cli = skeletonTestCli()
+	cli.Writer = &out
+	os.Args = []string{"cmd", "config", "init", pth}
+	assert.NoError(t, cli.Parse())
+	assert.Equal(t, "wrote "+pth+"\n", out.String())
+	byt, err := os.ReadFile(pth)
+	assert.NoError(t, err)
+	assert.Contains(t, string(byt), "server:\n  # protocol\n  protocol: \"http\"\n\n  app:\n")
+}
@@ -0,0 +1,225 @@
+package mycli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// ConfigError a key in a config file that no flag or command reads, or whose value its flag cannot take
+type ConfigError struct {
+	Position ConfigPosition
+	Key      string
+	Msg      string
+}
+
+func (e *ConfigError) Error() string {
+	if len(e.Position.File) == 0 {
+		return fmt.Sprintf("%s: %s", e.Key, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.Position, e.Key, e.Msg)
+}
+
+// ConfigErrors every problem found in the loaded config, one per line
+type ConfigErrors []error
+
+func (e ConfigErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// ValidateConfig checks the loaded config against the flags and commands. Global flags and command
+// tables are read at the root, a command table holds its flags and subcommand tables, and each flag
+// value must have the type of its flag. Hidden commands with a Variable and flags of custom types
+// read their whole value and are not checked. Problems are returned in key order.
+func (c *CLI) ValidateConfig() []error {
+	var errs []error
+	c.validateConfigTable(Toml().Map, "", c.Flgs, c.Cmds, &errs)
+	return errs
+}
+
+func (c *CLI) validateConfigTable(tbl map[string]interface{}, prefix string, flgs []CLIFlag, cmds []*CLICommand, errs *[]error) {
+	keys := make([]string, 0, len(tbl))
+	for k := range tbl {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		key := joinKey(prefix, k)
+		v := tbl[k]
+		if len(prefix) == 0 && k == ProfilesKey {
+			c.validateProfiles(v, errs)
+			continue
+		}
+		// values an applied profile set are checked in its table
+		if len(Toml().profileOf(key)) > 0 {
+			continue
+		}
+		if f := configFlag(flgs, k); f != nil {
+			if msg := configTypeProblem(f, v); len(msg) > 0 {
+				*errs = append(*errs, configError(key, msg))
+			}
+			continue
+		}
+		cmd := configCommand(cmds, k)
+		if cmd == nil {
+			*errs = append(*errs, configError(key, "unknown key"))
+			continue
+		}
+		if cmd.Hidden && cmd.Variable != nil {
+			continue
+		}
+		tables, ok := configTables(v)
+		if !ok {
+			*errs = append(*errs, configError(key, fmt.Sprintf("expected a table for command %s, got %s", cmd.Name, configTypeName(v))))
+			continue
+		}
+		// subcommand tables are only read one level down, in the table of a top-level command
+		var subcmds []*CLICommand
+		if configCommand(c.Cmds, k) == cmd {
+			subcmds = cmd.SubCommands
+		}
+		for _, t := range tables {
+			c.validateConfigTable(t, key, cmd.Flags, subcmds, errs)
+		}
+	}
+}
+
+// validateProfiles checks every profile like the root table, and that extends names another profile
+func (c *CLI) validateProfiles(v interface{}, errs *[]error) {
+	profiles, ok := v.(map[string]interface{})
+	if !ok {
+		*errs = append(*errs, configError(ProfilesKey, "expected a table of profiles, got "+configTypeName(v)))
+		return
+	}
+	names := make([]string, 0, len(profiles))
+	for n := range profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		key := ProfilesKey + "." + n
+		tbl, ok := profiles[n].(map[string]interface{})
+		if !ok {
+			*errs = append(*errs, configError(key, "expected a table for profile "+n+", got "+configTypeName(profiles[n])))
+			continue
+		}
+		rest := make(map[string]interface{}, len(tbl))
+		for k, val := range tbl {
+			rest[k] = val
+		}
+		if ext, ok := rest["extends"]; ok {
+			delete(rest, "extends")
+			if s, ok := ext.(string); !ok {
+				*errs = append(*errs, configError(key+".extends", "expected a string, got "+configTypeName(ext)))
+			} else if _, ok := profiles[s]; !ok {
+				*errs = append(*errs, configError(key+".extends", "profile "+s+" not found"))
+			}
+		}
+		c.validateConfigTable(rest, key, c.Flgs, c.Cmds, errs)
+	}
+}
+
+// checkConfigValue reports a value at key the flag cannot take
+func checkConfigValue(f CLIFlag, key string) error {
+	if msg := configTypeProblem(f, Toml().Get(key)); len(msg) > 0 {
+		return configError(key, msg)
+	}
+	return nil
+}
+
+func configError(key, msg string) *ConfigError {
+	return &ConfigError{Position: Toml().Position(key), Key: key, Msg: msg}
+}
+
+func configFlag(flgs []CLIFlag, name string) CLIFlag {
+	for _, f := range flgs {
+		if f.GName() == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func configCommand(cmds []*CLICommand, name string) *CLICommand {
+	for _, cmd := range cmds {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// configTables a table, or the tables of an array of tables
+func configTables(v interface{}) ([]map[string]interface{}, bool) {
+	switch node := v.(type) {
+	case nil:
+		// a YAML table whose keys are all commented out
+		return nil, true
+	case map[string]interface{}:
+		return []map[string]interface{}{node}, true
+	case []interface{}:
+		tables := make([]map[string]interface{}, 0, len(node))
+		for _, el := range node {
+			t, ok := el.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			tables = append(tables, t)
+		}
+		return tables, true
+	}
+	return nil, false
+}
+
+// configTypeProblem describes why v cannot be read by the flag, empty when it can or the flag is a custom type
+func configTypeProblem(f CLIFlag, v interface{}) string {
+	var err error
+	switch f.(type) {
+	case *BoolFlg:
+		_, err = CoerceBool(v)
+	case *Int64Flg:
+		_, err = CoerceInt64(v)
+	case *Uint64Flg:
+		_, err = CoerceUint64(v)
+	case *Float64Flg:
+		_, err = CoerceFloat64(v)
+	case *StringFlg, *SecretFlg:
+		_, err = CoerceString(v)
+	case *VarFlg:
+		_, err = CoerceStringList(v)
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// configTypeName names the type of a config value the way the config formats do
+func configTypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "nothing"
+	case bool:
+		return "boolean"
+	case int64, uint64:
+		return "integer"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "table"
+	case time.Time, toml.LocalDateTime, toml.LocalDate, toml.LocalTime:
+		return "datetime"
+	}
+	return fmt.Sprintf("%T", v)
+}
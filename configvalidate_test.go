@@ -0,0 +1,121 @@
+package mycli
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigPositions(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name, doc string
+		want      map[string]ConfigPosition
+	}{
+		{"a.toml", "capture = \"x\"\n[server]\n  port = 1\nsrv = { host = \"h\" }\n[[clients]]\nname = \"a\"\n",
+			map[string]ConfigPosition{"capture": {Line: 1, Column: 1}, "server": {Line: 2, Column: 2}, "server.port": {Line: 3, Column: 3},
+				"server.srv.host": {Line: 4, Column: 9}, "clients.name": {Line: 6, Column: 1}}},
+		{"a.yaml", "capture: x\nserver:\n  port: 1\nclients:\n  - name: a\n",
+			map[string]ConfigPosition{"capture": {Line: 1, Column: 1}, "server.port": {Line: 3, Column: 3}, "clients.name": {Line: 5, Column: 5}}},
+		{"a.json", "{\n  \"capture\": \"x\",\n  \"server\": {\"port\": 1},\n  \"clients\": [{\"name\": \"a\"}]\n}\n",
+			map[string]ConfigPosition{"capture": {Line: 2, Column: 3}, "server.port": {Line: 3, Column: 14}, "clients.name": {Line: 4, Column: 16}}},
+	}
+	for _, test := range tests {
+		pth := writeConfig(t, dir, test.name, test.doc)
+		var tw TomlWrapper
+		assert.NoError(t, tw.Load(pth, ""))
+		for key, want := range test.want {
+			want.File = pth
+			assert.Equal(t, want, tw.Position(key), test.name+" "+key)
+		}
+	}
+}
+
+func validateTestCli(strict bool) (*CLI, *string, *int64, *uint64, *StringList) {
+	var (
+		capture string
+		port    int64
+		workers uint64
+		hosts   StringList
+	)
+	c := NewCli(nil, nil)
+	c.TestMode = true
+	c.StrictConfig = strict
+	c.Flgs = []CLIFlag{
+		&StringFlg{Variable: &capture, Name: "capture", Value: "hello"},
+		&Uint64Flg{Variable: &workers, Name: "workers", Value: 1},
+		&VarFlg{Variable: &hosts, Name: "hosts"},
+	}
+	c.Cmds = []*CLICommand{
+		{
+			Name:   "server",
+			Action: func() {},
+			Flags:  []CLIFlag{&Int64Flg{Variable: &port, Name: "port", Value: 8080}},
+		},
+	}
+	return c, &capture, &port, &workers, &hosts
+}
+
+func TestStrictConfig(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	pth := writeConfig(t, t.TempDir(), "app.toml", "capture = 5\n[server]\nport = 9090\nprot = \"x\"\n")
+	cli, _, _, _, _ = validateTestCli(true)
+	os.Args = []string{"cmd", "-c", pth, "server"}
+	err := cli.Parse()
+	assert.Error(t, err)
+	errs, ok := err.(ConfigErrors)
+	assert.True(t, ok)
+	assert.Len(t, errs, 2)
+	assert.Equal(t, pth+":1:1: capture: expected a string, got integer 5", errs[0].Error())
+	assert.Equal(t, pth+":4:1: server.prot: unknown key", errs[1].Error())
+}
+
+func TestLenientConfigTypes(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	pth := writeConfig(t, t.TempDir(), "app.toml", "capture = 5\nworkers = 4\nhosts = [\"a\", \"b\"]\nextra = 1\n[server]\nport = \"x\"\n")
+	var capture *string
+	var port *int64
+	var workers *uint64
+	var hosts *StringList
+	cli, capture, port, workers, hosts = validateTestCli(false)
+	os.Args = []string{"cmd", "-c", pth, "server"}
+	assert.NoError(t, cli.Parse())
+	assert.Equal(t, "hello", *capture)
+	assert.Equal(t, int64(8080), *port)
+	assert.Equal(t, uint64(4), *workers)
+	assert.Equal(t, StringList{"a", "b"}, *hosts)
+}
+
+func TestConfigValidateCmd(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	dir := t.TempDir()
+	bad := writeConfig(t, dir, "bad.yaml", "server:\n  port: -1\nworkers: -2\n")
+	var out bytes.Buffer
+	cli, _, _, _, _ = validateTestCli(false)
+	cli.Writer = &out
+	os.Args = []string{"cmd", "config", "validate", bad}
+	err := cli.Parse()
+	assert.EqualError(t, err, "1 problem(s) found in "+bad)
+	assert.Equal(t, bad+":3:1: workers: expected a non-negative integer, got integer -2\n", out.String())
+}
+
+func TestConfigValidateCmdOk(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	good := writeConfig(t, t.TempDir(), "good.json", `{"workers": 2, "server": {"port": 1}}`)
+	var out bytes.Buffer
+	cli, _, _, _, _ = validateTestCli(false)
+	cli.Writer = &out
+	os.Args = []string{"cmd", "-c", good, "config", "validate"}
+	assert.NoError(t, cli.Parse())
+	assert.Equal(t, good+": ok\n", out.String())
+}
@@ -6,7 +6,6 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"os"
 	"reflect"
 
 	"github.com/colt3k/mycli"
@@ -74,6 +73,8 @@ type TomlFlg struct {
 	Action        interface{}
 	Options       []Clients
 	Hidden        bool
+	Category      string
+	Complete      mycli.CompleteFunc
 	debug         bool
 	debugLevel    int64
 	Command       string
@@ -185,7 +186,7 @@ func (c *TomlFlg) RetrieveEnvValue() error {
 	// pull from environment variable and convert to store as client object
 	fld := c.Variable.(*Clients)
 	if reflect.DeepEqual(*fld, c.Value) {
-		if envVal, found := os.LookupEnv(c.EnvVar); found {
+		if envVal, found := mycli.LookupEnv(c.EnvVar); found {
 			if c.debug {
 				log.Println("overriding " + c.Name + " with env variable setting '" + envVal + "'")
 			}
@@ -211,7 +212,7 @@ func (c *TomlFlg) RetrieveConfigValue(val *mycli.TomlWrapper, name string) error
 	// set to Variable here so no need to go further as in other types
 	err = json.Unmarshal(bytes, c.Variable)
 	if err != nil {
-		log.Fatalf("error unmarshalling\n%+v", err)
+		return fmt.Errorf("%s: cannot read config into %T: %v", c.Name, c.Variable, err)
 	}
 
 	return nil
@@ -267,6 +268,16 @@ func (c *TomlFlg) GHidden() bool {
 	return c.Hidden
 }
 
+// GCategory get help category for flag
+func (c *TomlFlg) GCategory() string {
+	return c.Category
+}
+
+// GComplete get value completion provider for flag
+func (c *TomlFlg) GComplete() mycli.CompleteFunc {
+	return c.Complete
+}
+
 // SetDebug set debug property for flag
 func (c *TomlFlg) SetDebug(dbg bool) {
 	c.debug = dbg
@@ -0,0 +1,336 @@
+package mycli
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GenerateMarkdownDocs writes <app>.md with the global options and command index, plus one
+// <app>_<command>[_<subcommand>].md file per visible command, cross-linked to each other.
+// It returns the paths written.
+func (c *CLI) GenerateMarkdownDocs(dir string) ([]string, error) {
+	c.prepare()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	name := c.appName()
+	written := make([]string, 0)
+
+	var byt bytes.Buffer
+	c.writeMarkdownIndex(&byt)
+	pth := filepath.Join(dir, name+".md")
+	if err := os.WriteFile(pth, byt.Bytes(), 0644); err != nil {
+		return written, err
+	}
+	written = append(written, pth)
+
+	var err error
+	walkCommands(c.Cmds, nil, func(path []*CLICommand) {
+		if err != nil {
+			return
+		}
+		byt.Reset()
+		c.writeMarkdownCommand(&byt, path)
+		pth := filepath.Join(dir, docFileName(name, path)+".md")
+		if err = os.WriteFile(pth, byt.Bytes(), 0644); err != nil {
+			return
+		}
+		written = append(written, pth)
+	})
+	return written, err
+}
+
+func (c *CLI) writeMarkdownIndex(byt *bytes.Buffer) {
+	name := c.appName()
+	title := name
+	if len(c.Title) > 0 {
+		title += " - " + c.Title
+	}
+	byt.WriteString("# " + title + "\n\n")
+	if len(c.Description) > 0 {
+		byt.WriteString(c.Description + "\n\n")
+	}
+	byt.WriteString("## Usage\n\n```\n")
+	if len(c.AppInfo.Usage) > 0 {
+		byt.WriteString(c.AppInfo.Usage + "\n")
+	} else {
+		byt.WriteString(name + " [global options] command [command options] [arguments...]\n")
+	}
+	byt.WriteString("```\n\n")
+
+	if flgs := visibleFlags(c.Flgs); len(flgs) > 0 {
+		byt.WriteString("## Global Options\n\n")
+		writeMarkdownFlags(byt, flgs, nil)
+	}
+
+	wroteHeader := false
+	walkCommands(c.Cmds, nil, func(path []*CLICommand) {
+		if !wroteHeader {
+			byt.WriteString("## Commands\n\n")
+			wroteHeader = true
+		}
+		indent := strings.Repeat("  ", len(path)-1)
+		cmd := path[len(path)-1]
+		fmt.Fprintf(byt, "%s- [%s](%s.md)", indent, commandPath(path), docFileName(name, path))
+		if len(cmd.Usage) > 0 {
+			byt.WriteString(" - " + markdownText(firstLine(cmd.Usage)))
+		}
+		byt.WriteString("\n")
+	})
+	if wroteHeader {
+		byt.WriteString("\n")
+	}
+	c.writeMarkdownFooter(byt)
+}
+
+func (c *CLI) writeMarkdownCommand(byt *bytes.Buffer, path []*CLICommand) {
+	name := c.appName()
+	cmd := path[len(path)-1]
+
+	byt.WriteString("# " + name + " " + commandPath(path) + "\n\n")
+	if len(cmd.Usage) > 0 {
+		byt.WriteString(markdownText(cmd.Usage) + "\n\n")
+	}
+	byt.WriteString("```\n" + name + " [global options] " + commandPath(path))
+	if len(cmd.SubCommands) > 0 {
+		byt.WriteString(" [subcommand]")
+	}
+	byt.WriteString(" [command options] [arguments...]\n```\n\n")
+	if len(cmd.ShortName) > 0 {
+		byt.WriteString("Alias: `" + cmd.ShortName + "`\n\n")
+	}
+
+	if flgs := visibleFlags(cmd.Flags); len(flgs) > 0 {
+		byt.WriteString("## Options\n\n")
+		writeMarkdownFlags(byt, flgs, path)
+	}
+
+	subs := make([]*CLICommand, 0)
+	for _, k := range cmd.SubCommands {
+		if !k.Hidden {
+			subs = append(subs, k)
+		}
+	}
+	if len(subs) > 0 {
+		byt.WriteString("## Subcommands\n\n")
+		for _, k := range subs {
+			sub := append(append([]*CLICommand{}, path...), k)
+			fmt.Fprintf(byt, "- [%s](%s.md)", k.Name, docFileName(name, sub))
+			if len(k.Usage) > 0 {
+				byt.WriteString(" - " + markdownText(firstLine(k.Usage)))
+			}
+			byt.WriteString("\n")
+		}
+		byt.WriteString("\n")
+	}
+
+	if len(cmd.Examples) > 0 {
+		byt.WriteString("## Examples\n\n")
+		for _, e := range cmd.Examples {
+			if len(e.Description) > 0 {
+				byt.WriteString(e.Description + "\n\n")
+			}
+			byt.WriteString("```\n" + e.Cmd + "\n```\n\n")
+		}
+	}
+
+	byt.WriteString("## See Also\n\n")
+	fmt.Fprintf(byt, "- [%s](%s.md)\n", name, name)
+	if len(path) > 1 {
+		parent := path[:len(path)-1]
+		fmt.Fprintf(byt, "- [%s](%s.md)\n", commandPath(parent), docFileName(name, parent))
+	}
+	byt.WriteString("\n")
+}
+
+func (c *CLI) writeMarkdownFooter(byt *bytes.Buffer) {
+	if len(c.Author) > 0 {
+		byt.WriteString("## Author\n\n" + c.Author + "\n\n")
+	}
+	if len(c.Copyright) > 0 {
+		byt.WriteString("## Copyright\n\n" + c.Copyright + "\n\n")
+	}
+}
+
+// writeMarkdownFlags writes the flag reference table, path is the command the flags belong to, nil for globals.
+func writeMarkdownFlags(byt *bytes.Buffer, flgs []CLIFlag, path []*CLICommand) {
+	byt.WriteString("| Name | Short | Type | Default | Env Var | Config Key | Options | Required | Description |\n")
+	byt.WriteString("| --- | --- | --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, r := range docFlagRows(flgs, path) {
+		cells := make([]string, 0, len(r))
+		for i, v := range r {
+			v = strings.Replace(v, "|", "\\|", -1)
+			v = strings.Replace(v, "\n", " ", -1)
+			// code style for everything but the required column and the description
+			if len(v) > 0 && i < 7 {
+				v = "`" + v + "`"
+			} else {
+				v = markdownText(v)
+			}
+			cells = append(cells, v)
+		}
+		byt.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	byt.WriteString("\n")
+}
+
+// WriteHTMLDocs writes the whole command reference as a single HTML page with anchors per command.
+func (c *CLI) WriteHTMLDocs(w io.Writer) error {
+	c.prepare()
+	name := c.appName()
+	title := name
+	if len(c.Title) > 0 {
+		title += " - " + c.Title
+	}
+
+	var byt bytes.Buffer
+	byt.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	byt.WriteString("<title>" + html.EscapeString(title) + "</title>\n</head>\n<body>\n")
+	byt.WriteString("<h1>" + html.EscapeString(title) + "</h1>\n")
+	if len(c.Description) > 0 {
+		byt.WriteString("<p>" + html.EscapeString(c.Description) + "</p>\n")
+	}
+	usage := name + " [global options] command [command options] [arguments...]"
+	if len(c.AppInfo.Usage) > 0 {
+		usage = c.AppInfo.Usage
+	}
+	byt.WriteString("<pre>" + html.EscapeString(usage) + "</pre>\n")
+
+	if flgs := visibleFlags(c.Flgs); len(flgs) > 0 {
+		byt.WriteString("<h2 id=\"global-options\">Global Options</h2>\n")
+		writeHTMLFlags(&byt, flgs, nil)
+	}
+
+	// command index followed by one section per command
+	index := make([]string, 0)
+	sections := make([]string, 0)
+	walkCommands(c.Cmds, nil, func(path []*CLICommand) {
+		cmd := path[len(path)-1]
+		anchor := docFileName(name, path)
+		index = append(index, fmt.Sprintf("<li style=\"margin-left:%dem\"><a href=\"#%s\">%s</a> %s</li>",
+			2*(len(path)-1), anchor, html.EscapeString(commandPath(path)), html.EscapeString(firstLine(cmd.Usage))))
+
+		var sec bytes.Buffer
+		sec.WriteString(fmt.Sprintf("<h3 id=\"%s\">%s</h3>\n", anchor, html.EscapeString(name+" "+commandPath(path))))
+		if len(cmd.Usage) > 0 {
+			sec.WriteString("<p>" + html.EscapeString(cmd.Usage) + "</p>\n")
+		}
+		if len(cmd.ShortName) > 0 {
+			sec.WriteString("<p>Alias: <code>" + html.EscapeString(cmd.ShortName) + "</code></p>\n")
+		}
+		if flgs := visibleFlags(cmd.Flags); len(flgs) > 0 {
+			writeHTMLFlags(&sec, flgs, path)
+		}
+		subs := make([]string, 0)
+		for _, k := range cmd.SubCommands {
+			if !k.Hidden {
+				sub := append(append([]*CLICommand{}, path...), k)
+				subs = append(subs, fmt.Sprintf("<li><a href=\"#%s\">%s</a></li>", docFileName(name, sub), html.EscapeString(k.Name)))
+			}
+		}
+		if len(subs) > 0 {
+			sec.WriteString("<p>Subcommands:</p>\n<ul>\n" + strings.Join(subs, "\n") + "\n</ul>\n")
+		}
+		for _, e := range cmd.Examples {
+			if len(e.Description) > 0 {
+				sec.WriteString("<p>" + html.EscapeString(e.Description) + "</p>\n")
+			}
+			sec.WriteString("<pre>" + html.EscapeString(e.Cmd) + "</pre>\n")
+		}
+		sections = append(sections, sec.String())
+	})
+	if len(index) > 0 {
+		byt.WriteString("<h2 id=\"commands\">Commands</h2>\n<ul>\n" + strings.Join(index, "\n") + "\n</ul>\n")
+		byt.WriteString(strings.Join(sections, ""))
+	}
+
+	if len(c.Author) > 0 {
+		byt.WriteString("<h2>Author</h2>\n<p>" + html.EscapeString(c.Author) + "</p>\n")
+	}
+	if len(c.Copyright) > 0 {
+		byt.WriteString("<h2>Copyright</h2>\n<p>" + html.EscapeString(c.Copyright) + "</p>\n")
+	}
+	byt.WriteString("</body>\n</html>\n")
+
+	_, err := w.Write(byt.Bytes())
+	return err
+}
+
+func writeHTMLFlags(byt *bytes.Buffer, flgs []CLIFlag, path []*CLICommand) {
+	byt.WriteString("<table>\n<tr><th>Name</th><th>Short</th><th>Type</th><th>Default</th><th>Env Var</th><th>Config Key</th><th>Options</th><th>Required</th><th>Description</th></tr>\n")
+	for _, r := range docFlagRows(flgs, path) {
+		byt.WriteString("<tr>")
+		for _, v := range r {
+			byt.WriteString("<td>" + html.EscapeString(v) + "</td>")
+		}
+		byt.WriteString("</tr>\n")
+	}
+	byt.WriteString("</table>\n")
+}
+
+// docFlagRows returns the reference table cells for each flag: name, short, type, default, env var,
+// config key, options, required and description.
+func docFlagRows(flgs []CLIFlag, path []*CLICommand) [][]string {
+	rows := make([][]string, 0, len(flgs))
+	for _, f := range flgs {
+		short := ""
+		if len(strings.TrimSpace(f.GShortName())) > 0 {
+			short = "-" + f.GShortName()
+		}
+		required := ""
+		if f.GRequired() {
+			required = "yes"
+		}
+		rows = append(rows, []string{
+			"-" + f.GName(),
+			short,
+			flagType(f),
+			defaultText(f),
+			f.GEnvVar(),
+			configKey(path, f),
+			optionsText(f),
+			required,
+			f.GUsage(),
+		})
+	}
+	return rows
+}
+
+// markdownText keeps angle brackets in usage text from being read as html.
+func markdownText(s string) string {
+	s = strings.Replace(s, "<", "&lt;", -1)
+	return strings.Replace(s, ">", "&gt;", -1)
+}
+
+// flagType names the value type of a flag for reference docs.
+func flagType(f CLIFlag) string {
+	if tmp := f.UnquotedUsage(); len(tmp) > 0 {
+		return tmp
+	}
+	switch f.(type) {
+	case *BoolFlg:
+		return "bool"
+	case *VarFlg:
+		return "list"
+	}
+	return ""
+}
+
+// configKey is the dotted config file key parseConfigFile reads the flag from.
+func configKey(path []*CLICommand, f CLIFlag) string {
+	keys := make([]string, 0, len(path)+1)
+	for _, p := range path {
+		keys = append(keys, p.Name)
+	}
+	return strings.Join(append(keys, f.GName()), ".")
+}
+
+// docFileName names a command document, i.e. "myapp_weserve_config".
+func docFileName(app string, path []*CLICommand) string {
+	return app + "_" + strings.Replace(commandPath(path), " ", "_", -1)
+}
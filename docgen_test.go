@@ -0,0 +1,53 @@
+package mycli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateMarkdownDocs(t *testing.T) {
+	c := manTestCli()
+	dir := t.TempDir()
+
+	written, err := c.GenerateMarkdownDocs(dir)
+	assert.NoError(t, err)
+
+	name := c.appName()
+	assert.Contains(t, written, filepath.Join(dir, name+".md"))
+	assert.Contains(t, written, filepath.Join(dir, name+"_weserve.md"))
+	assert.Contains(t, written, filepath.Join(dir, name+"_weserve_config.md"))
+	assert.NotContains(t, written, filepath.Join(dir, name+"_secret.md"))
+
+	index, err := os.ReadFile(filepath.Join(dir, name+".md"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(index), "| `-capture` | `-cap` | `string` | `hello` | `T_CAPTURE` | `capture` | `[hello bye]` |  | capture mode |")
+	assert.Contains(t, string(index), "  - [weserve config]("+name+"_weserve_config.md) - use config file")
+
+	parent, err := os.ReadFile(filepath.Join(dir, name+"_weserve.md"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(parent), "- [config]("+name+"_weserve_config.md)")
+
+	sub, err := os.ReadFile(filepath.Join(dir, name+"_weserve_config.md"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(sub), "| `-application` |  | `string` |  | `T_APPLICATION` | `weserve.config.application` |  | yes | application name |")
+	assert.Contains(t, string(sub), "- [weserve]("+name+"_weserve.md)")
+}
+
+func TestWriteHTMLDocs(t *testing.T) {
+	c := manTestCli()
+
+	var byt bytes.Buffer
+	err := c.WriteHTMLDocs(&byt)
+	assert.NoError(t, err)
+
+	page := byt.String()
+	name := c.appName()
+	assert.Contains(t, page, "<h3 id=\""+name+"_weserve_config\">")
+	assert.Contains(t, page, "<a href=\"#"+name+"_weserve_config\">weserve config</a>")
+	assert.Contains(t, page, "<td>weserve.config.port</td>")
+	assert.NotContains(t, page, "hidden diagnostics")
+}
@@ -0,0 +1,158 @@
+package mycli
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/colt3k/nglog/ng"
+)
+
+// envFileValues values read from dotenv files, consulted by LookupEnv after the process environment
+var envFileValues = make(map[string]string)
+
+// dotenvKey names a dotenv file accepts, hyphens included as WriteEffectiveConfig writes them for such flags
+var dotenvKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// LookupEnv returns the value of an environment variable, from the process environment or else from
+// the dotenv files Parse loaded. Flags read their EnvVar through it.
+func LookupEnv(key string) (string, bool) {
+	if v, ok := os.LookupEnv(key); ok {
+		return v, ok
+	}
+	v, ok := envFileValues[key]
+	return v, ok
+}
+
+// loadEnvFiles reads CLI.EnvFiles, skipping missing ones, then every -env-file, later files win
+func (c *CLI) loadEnvFiles() error {
+	envFileValues = make(map[string]string)
+	files := make([]string, 0, len(c.EnvFiles)+len(envfiles))
+	for _, p := range c.EnvFiles {
+		p = FixPath(p)
+		if _, err := os.Stat(p); err == nil {
+			files = append(files, p)
+		}
+	}
+	for _, p := range envfiles {
+		if len(strings.TrimSpace(p)) > 0 {
+			files = append(files, FixPath(p))
+		}
+	}
+	for _, p := range files {
+		byt, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		vals, err := ParseDotenv(byt)
+		if err != nil {
+			return fmt.Errorf("%s: %v", p, err)
+		}
+		if Debug && !GenerateBashCompletion {
+			ng.Logf(ng.DEBUG, "env file %v", p)
+		}
+		for k, v := range vals {
+			envFileValues[k] = v
+		}
+	}
+	return nil
+}
+
+// ParseDotenv reads KEY=value lines. Blank lines and lines starting with # are skipped, and an export
+// prefix is allowed. Unquoted values end at a # preceded by a space and are trimmed. Single quoted values
+// are taken as written, double quoted values read \n, \r, \t, \", \\, \$ and \` escapes, and both may
+// span lines. A key given twice keeps the last value.
+func ParseDotenv(data []byte) (map[string]string, error) {
+	vals := make(map[string]string)
+	src := strings.ReplaceAll(string(data), "\r\n", "\n")
+	line := 0
+	for len(src) > 0 {
+		line++
+		var l string
+		if i := strings.IndexByte(src, '\n'); i > -1 {
+			l, src = src[:i], src[i+1:]
+		} else {
+			l, src = src, ""
+		}
+		// only the left side is trimmed, a quoted value keeps its trailing spaces
+		l = strings.TrimLeft(l, " \t")
+		if len(strings.TrimSpace(l)) == 0 || strings.HasPrefix(l, "#") {
+			continue
+		}
+		if rest := strings.TrimPrefix(l, "export"); rest != l && len(rest) > 0 && (rest[0] == ' ' || rest[0] == '\t') {
+			l = strings.TrimSpace(rest)
+		}
+		eq := strings.IndexByte(l, '=')
+		if eq < 0 || !dotenvKey.MatchString(strings.TrimSpace(l[:eq])) {
+			return nil, fmt.Errorf("line %d: expected KEY=value", line)
+		}
+		key, val := strings.TrimSpace(l[:eq]), strings.TrimLeft(l[eq+1:], " \t")
+		if len(val) == 0 || (val[0] != '"' && val[0] != '\'') {
+			if i := strings.Index(val, " #"); i > -1 {
+				val = val[:i]
+			}
+			if i := strings.Index(val, "\t#"); i > -1 {
+				val = val[:i]
+			}
+			vals[key] = strings.TrimSpace(val)
+			continue
+		}
+		// a quoted value may continue on the following lines
+		start := line
+		quote := val[0]
+		text := val[1:]
+		var b strings.Builder
+		for {
+			end, ok := dotenvQuoted(text, quote, &b)
+			if ok {
+				if rest := strings.TrimSpace(text[end:]); len(rest) > 0 && !strings.HasPrefix(rest, "#") {
+					return nil, fmt.Errorf("line %d: unexpected %q after the closing quote", line, rest)
+				}
+				break
+			}
+			if len(src) == 0 {
+				return nil, fmt.Errorf("line %d: unterminated quoted value", start)
+			}
+			b.WriteByte('\n')
+			line++
+			if i := strings.IndexByte(src, '\n'); i > -1 {
+				text, src = src[:i], src[i+1:]
+			} else {
+				text, src = src, ""
+			}
+		}
+		vals[key] = b.String()
+	}
+	return vals, nil
+}
+
+// dotenvQuoted writes the quoted text up to the closing quote and returns the offset past it, ok is
+// false when the line ends first
+func dotenvQuoted(text string, quote byte, b *strings.Builder) (int, bool) {
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		switch {
+		case ch == quote:
+			return i + 1, true
+		case ch == '\\' && quote == '"' && i+1 < len(text):
+			i++
+			switch text[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$', '`':
+				b.WriteByte(text[i])
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(text[i])
+			}
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return len(text), false
+}
@@ -0,0 +1,98 @@
+package mycli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDotenv(t *testing.T) {
+	vals, err := ParseDotenv([]byte(`# local settings
+T_PLAIN=hello world  # trailing comment
+export T_EXPORTED = yes
+T_HASH=a#b
+T_EMPTY=
+T_SINGLE='keep \n and $HOME' # comment
+T_DOUBLE="tab\there \"quoted\" \$HOME"
+T_MULTI="first
+  second"
+T_CERT='-----BEGIN-----
+abc
+-----END-----'
+T_DB-PASSWORD=<redacted>
+T_PLAIN=again
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"T_PLAIN":       "again",
+		"T_EXPORTED":    "yes",
+		"T_HASH":        "a#b",
+		"T_EMPTY":       "",
+		"T_SINGLE":      `keep \n and $HOME`,
+		"T_DOUBLE":      "tab\there \"quoted\" $HOME",
+		"T_MULTI":       "first\n  second",
+		"T_CERT":        "-----BEGIN-----\nabc\n-----END-----",
+		"T_DB-PASSWORD": "<redacted>",
+	}, vals)
+
+	_, err = ParseDotenv([]byte("T_A=1\nnot a pair\n"))
+	assert.EqualError(t, err, "line 2: expected KEY=value")
+	_, err = ParseDotenv([]byte("T_A=1\nT_B=\"open\nstill open\n"))
+	assert.EqualError(t, err, "line 2: unterminated quoted value")
+	_, err = ParseDotenv([]byte("T_A=\"x\" y\n"))
+	assert.EqualError(t, err, `line 1: unexpected "y" after the closing quote`)
+}
+
+func TestDotenvQuoteRoundTrip(t *testing.T) {
+	for _, s := range []string{"plain", "", "with space", "it's", `say "hi"`, "a\nb\r\nc", `back\slash`, "$HOME and `cmd`", "k=v", "#hash"} {
+		vals, err := ParseDotenv([]byte("T_X=" + dotenvQuote(s) + "\n"))
+		assert.NoError(t, err, s)
+		assert.Equal(t, s, vals["T_X"], s)
+	}
+}
+
+func TestEnvFiles(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	dir := t.TempDir()
+	base := writeConfig(t, dir, ".env", "T_CAPTURE=from-env-file\nT_RATIO=0.5\nT_HOST=base\n")
+	local := writeConfig(t, dir, "local.env", "T_HOST=local\n")
+	os.Setenv("T_RATIO", "0.75")
+	defer os.Unsetenv("T_RATIO")
+
+	var (
+		capture, host string
+		ratio         float64
+	)
+	parse := func(args ...string) error {
+		ResetForTesting(nil)
+		cli = NewCli(nil, nil)
+		cli.TestMode = true
+		cli.DisableEnvVars = false
+		cli.MainAction = func() {}
+		cli.EnvFiles = []string{base, filepath.Join(dir, "missing.env")}
+		cli.Flgs = []CLIFlag{
+			&StringFlg{Variable: &capture, Name: "capture"},
+			&StringFlg{Variable: &host, Name: "host"},
+			&Float64Flg{Variable: &ratio, Name: "ratio"},
+		}
+		os.Args = append([]string{"cmd"}, args...)
+		return cli.Parse()
+	}
+	assert.NoError(t, parse())
+	assert.Equal(t, "from-env-file", capture)
+	assert.Equal(t, "base", host)
+	assert.Equal(t, 0.75, ratio)
+
+	assert.NoError(t, parse("-env-file", local))
+	assert.Equal(t, "local", host)
+	v, ok := LookupEnv("T_HOST")
+	assert.True(t, ok)
+	assert.Equal(t, "local", v)
+
+	missing := filepath.Join(dir, "nope.env")
+	assert.EqualError(t, parse("-env-file", missing), "open "+missing+": no such file or directory")
+}
@@ -109,11 +109,12 @@ func setupFlags() {
 	//c.EnvPrefix = ""
 	c.Flgs = []mycli.CLIFlag{
 		&mycli.StringFlg{Variable: &capture, Name: "capture", ShortName: "cap", Usage: "Used to test string", Options: []string{"hello", "bye"}},
-		&mycli.StringFlg{Variable: &path, Name: "path", Usage: "Used to test path with slash"},
+		&mycli.StringFlg{Variable: &path, Name: "path", Usage: "Used to test path with slash", Complete: mycli.CompleteFiles},
 		&mycli.StringFlg{Variable: &url, Name: "url", Usage: "Used to test url with slashes"},
 		&custom.TomlFlg{Variable: &clients, Name: "clients", Usage: "Set name to toml table type"},
-		&mycli.StringFlg{Variable: &fieldName, Name: "fieldname", ShortName: "fn", Usage: "field name(s) (CamelCase) to show, comma separated in double quotes", Value: "MaxLength0"},
+		&mycli.StringFlg{Variable: &fieldName, Name: "fieldname", ShortName: "fn", Usage: "field name(s) (CamelCase) to show, comma separated in double quotes", Value: "MaxLength0", Category: mycli.CategoryOutput},
 	}
+	c.Categories = []string{mycli.CategoryOutput, mycli.CategoryNetworking, mycli.CategoryDebugging}
 
 	c.Cmds = []*mycli.CLICommand{
 		{
@@ -124,6 +125,10 @@ func setupFlags() {
 			Action:     func() { runAsServer(fieldName) },
 			PreAction:  func() { checkDebug("cmd") },
 			PostAction: nil,
+			Examples: []mycli.Example{
+				{Cmd: appName + " server -protocol https -port 9090", Description: "serve over https on port 9090"},
+				{Cmd: appName + " -c example/config.toml server", Description: "take server settings from a config file"},
+			},
 			Flags: []mycli.CLIFlag{
 				&mycli.StringFlg{Variable: &protocol, Name: "protocol", ShortName: "proto", Usage: "Set Protocol http(s)", Value: "http"},
 				// if value is set and required passed in value has to be different or it will think it wasn't set
@@ -13,6 +13,8 @@ type baseFlag struct {
 // CLIFlag describes the behavior each concrete flag type must implement.
 type CLIFlag interface {
 	GAction() interface{}
+	GCategory() string
+	GComplete() CompleteFunc
 	GEnvVar() string
 	GEnvVarExclude() bool
 	GHidden() bool
@@ -58,14 +60,3 @@ func (c *CLI) requiredMessaging(subCmd string, f CLIFlag) {
 	}
 	fmt.Println()
 }
-
-// retrieveEnvVal if not set on commandline pull from environment
-func (c *CLI) retrieveEnvVal(glblFlgs []CLIFlag) error {
-	for _, f := range glblFlgs {
-		err := f.RetrieveEnvValue()
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
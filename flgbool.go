@@ -4,7 +4,6 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"os"
 	"reflect"
 	"strconv"
 )
@@ -24,6 +23,8 @@ type BoolFlg struct {
 	Action        interface{}
 	Options       []bool
 	Hidden        bool
+	Category      string
+	Complete      CompleteFunc
 	debug         bool
 	debugLevel    int64
 }
@@ -107,7 +108,7 @@ func (c *BoolFlg) GOptions() interface{} {
 func (c *BoolFlg) RetrieveEnvValue() error {
 	fld := c.Variable.(*bool)
 	if *fld == c.Value {
-		if envVal, found := os.LookupEnv(c.EnvVar); found {
+		if envVal, found := LookupEnv(c.EnvVar); found {
 			if c.debug {
 				log.Println("overriding " + c.Name + " with env variable setting '" + envVal + "'")
 			}
@@ -127,7 +128,10 @@ func (c *BoolFlg) RetrieveConfigValue(val *TomlWrapper, name string) error {
 	//	name = c.Name
 	//}
 
-	curVal = val.Get(name).(bool)
+	curVal, err := CoerceBool(val.Get(name))
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
 	fld := c.Variable.(*bool)
 	if *fld == c.Value {
 		if c.debug {
@@ -181,6 +185,12 @@ func (c *BoolFlg) Kind() error {
 func (c *BoolFlg) GHidden() bool {
 	return c.Hidden
 }
+func (c *BoolFlg) GCategory() string {
+	return c.Category
+}
+func (c *BoolFlg) GComplete() CompleteFunc {
+	return c.Complete
+}
 func (c *BoolFlg) SetDebug(dbg bool) {
 	c.debug = dbg
 }
@@ -4,7 +4,6 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"os"
 	"reflect"
 	"strconv"
 )
@@ -24,6 +23,8 @@ type Float64Flg struct {
 	Action        interface{}
 	Options       []float64
 	Hidden        bool
+	Category      string
+	Complete      CompleteFunc
 	debug         bool
 	debugLevel    int64
 }
@@ -107,7 +108,7 @@ func (c *Float64Flg) GOptions() interface{} {
 func (c *Float64Flg) RetrieveEnvValue() error {
 	fld := c.Variable.(*float64)
 	if *fld == c.Value {
-		if envVal, found := os.LookupEnv(c.EnvVar); found {
+		if envVal, found := LookupEnv(c.EnvVar); found {
 			if c.debug {
 				log.Println("overriding " + c.Name + " with env variable setting '" + envVal + "'")
 			}
@@ -126,7 +127,10 @@ func (c *Float64Flg) RetrieveConfigValue(val *TomlWrapper, name string) error {
 	//if len(c.Command) == 0 {
 	//	name = c.Name
 	//}
-	curVal = val.Get(name).(float64)
+	curVal, err := CoerceFloat64(val.Get(name))
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
 	fld := c.Variable.(*float64)
 	if *fld == c.Value {
 		if c.debug {
@@ -180,6 +184,12 @@ func (c *Float64Flg) Kind() error {
 func (c *Float64Flg) GHidden() bool {
 	return c.Hidden
 }
+func (c *Float64Flg) GCategory() string {
+	return c.Category
+}
+func (c *Float64Flg) GComplete() CompleteFunc {
+	return c.Complete
+}
 func (c *Float64Flg) SetDebug(dbg bool) {
 	c.debug = dbg
 }
@@ -4,7 +4,6 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -25,6 +24,8 @@ type Int64Flg struct {
 	Action        interface{}
 	Options       []int64
 	Hidden        bool
+	Category      string
+	Complete      CompleteFunc
 	debug         bool
 	debugLevel    int64
 }
@@ -108,7 +109,7 @@ func (c *Int64Flg) GOptions() interface{} {
 func (c *Int64Flg) RetrieveEnvValue() error {
 	fld := c.Variable.(*int64)
 	if *fld == c.Value {
-		if envVal, found := os.LookupEnv(c.EnvVar); found {
+		if envVal, found := LookupEnv(c.EnvVar); found {
 			var err error
 			if c.debug {
 				log.Println("overriding " + c.Name + " with env variable setting '" + envVal + "'")
@@ -130,7 +131,10 @@ func (c *Int64Flg) RetrieveConfigValue(val *TomlWrapper, name string) error {
 	//if len(c.Command) == 0 {
 	//	name = c.Name
 	//}
-	curVal = val.Get(name).(int64)
+	curVal, err := CoerceInt64(val.Get(name))
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
 	fld := c.Variable.(*int64)
 	if *fld == c.Value {
 		if c.debug {
@@ -184,6 +188,12 @@ func (c *Int64Flg) Kind() error {
 func (c *Int64Flg) GHidden() bool {
 	return c.Hidden
 }
+func (c *Int64Flg) GCategory() string {
+	return c.Category
+}
+func (c *Int64Flg) GComplete() CompleteFunc {
+	return c.Complete
+}
 func (c *Int64Flg) SetDebug(dbg bool) {
 	c.debug = dbg
 }
@@ -0,0 +1,244 @@
+package mycli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// SecretFlg implements CLIFlag for passwords, tokens and keys. The value may be given as @/path to read
+// it from a file, and EnvVar_FILE names a file to read when EnvVar is not set. It is never shown in help,
+// debug output, printed configuration or warnings.
+type SecretFlg struct {
+	baseFlag
+	Variable      interface{}
+	Name          string
+	ShortName     string
+	Usage         string
+	EnvVar        string
+	EnvVarExclude bool
+	Value         string
+	Required      bool
+	Action        interface{}
+	Hidden        bool
+	Category      string
+	Complete      CompleteFunc
+	debug         bool
+	debugLevel    int64
+}
+
+// secretValue reads @file values when the flag is set and keeps its default out of the flag package
+type secretValue struct {
+	p *string
+}
+
+func (s secretValue) String() string {
+	if s.p == nil || len(*s.p) == 0 {
+		return ""
+	}
+	return Redacted
+}
+func (s secretValue) Set(v string) error {
+	val, err := ReadSecret(v)
+	if err != nil {
+		return err
+	}
+	*s.p = val
+	return nil
+}
+
+// ReadSecret returns the content of the file a value of the form @/path names, without trailing newlines.
+// Other values are returned as they are, @@ starts a value with a literal @.
+func ReadSecret(v string) (string, error) {
+	if !strings.HasPrefix(v, "@") {
+		return v, nil
+	}
+	if strings.HasPrefix(v, "@@") {
+		return v[1:], nil
+	}
+	byt, err := os.ReadFile(FixPath(ExpandConfigString(v[1:])))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(byt), "\r\n"), nil
+}
+
+func (c *SecretFlg) AdjustValue(cmd string, flgValues map[string]interface{}) {
+	for k, v := range flgValues {
+		if k == cmd+"_"+c.Name {
+			fld := c.Variable.(*string)
+			*fld = v.(string)
+		}
+	}
+}
+
+func (c *SecretFlg) BuildFlag(flgSet *flag.FlagSet, varMap map[string][]FieldPtr, flgValues map[string]interface{}) {
+	// obtain variable field pointer
+	fld := c.Variable.(*string)
+	flgSet.Var(secretValue{fld}, c.Name, c.Usage)
+	if len(c.ShortName) > 0 {
+		flgSet.Var(secretValue{fld}, c.ShortName, c.Usage)
+	}
+	// set value to memory pointer of variable
+	*fld = c.Value
+	flgValues[c.Command+"_"+c.Name] = *fld
+	// Map Any Duplicate Pointer issues for Variables and warn user, validateVariables redacts secret values
+	if v, ok := varMap[fmt.Sprintf("%p", c.Variable)]; ok {
+		// Don't add same thing twice
+		if v[0].FieldName != c.Name || v[0].Command != c.Command {
+			// found add to array
+			v = append(v, FieldPtr{FieldName: c.Name, Command: c.Command, Address: fmt.Sprintf("%p", c.Variable), Value: *fld, ValType: "secret"})
+			varMap[fmt.Sprintf("%p", c.Variable)] = v
+		}
+	} else {
+		// create array
+		t := make([]FieldPtr, 0)
+		t = append(t, FieldPtr{FieldName: c.Name, Command: c.Command, Address: fmt.Sprintf("%p", c.Variable), Value: *fld, ValType: "secret"})
+		varMap[fmt.Sprintf("%p", c.Variable)] = t
+	}
+}
+func (c *SecretFlg) GCommand(cmd string) {
+	c.Command = cmd
+}
+func (c *SecretFlg) GVariable() interface{} {
+	return c.Variable
+}
+
+// GVariableToString returns Redacted when a value is set, read the Variable for the value itself
+func (c *SecretFlg) GVariableToString() string {
+	return secretValue{c.Variable.(*string)}.String()
+}
+func (c *SecretFlg) SetEnvVar(envVar string) {
+	c.EnvVar = envVar
+}
+func (c *SecretFlg) GName() string {
+	return c.Name
+}
+func (c *SecretFlg) GShortName() string {
+	return c.ShortName
+}
+func (c *SecretFlg) GUsage() string {
+	return c.Usage
+}
+func (c *SecretFlg) GEnvVar() string {
+	return c.EnvVar
+}
+func (c *SecretFlg) GEnvVarExclude() bool {
+	return c.EnvVarExclude
+}
+func (c *SecretFlg) GValue() interface{} {
+	return c.Value
+}
+func (c *SecretFlg) GRequired() bool {
+	return c.Required
+}
+func (c *SecretFlg) GAction() interface{} {
+	return c.Action
+}
+func (c *SecretFlg) GOptions() interface{} {
+	return []string(nil)
+}
+
+// RetrieveEnvValue reads EnvVar, or the file named by EnvVar_FILE when EnvVar is not set
+func (c *SecretFlg) RetrieveEnvValue() error {
+	fld := c.Variable.(*string)
+	if *fld != c.Value || len(c.EnvVar) == 0 {
+		return nil
+	}
+	name, envVal, found := c.EnvVar, "", false
+	if envVal, found = LookupEnv(name); !found {
+		name = c.EnvVar + "_FILE"
+		if envVal, found = LookupEnv(name); found {
+			envVal = "@" + envVal
+		}
+	}
+	if !found {
+		return nil
+	}
+	val, err := ReadSecret(envVal)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	if c.debug {
+		log.Println("overriding " + c.Name + " with env variable " + name)
+	}
+	*fld = val
+	return nil
+}
+func (c *SecretFlg) RetrieveConfigValue(val *TomlWrapper, name string) error {
+	curVal, err := CoerceString(val.Get(name))
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	// a relative @file is read next to the config file that names it
+	if p := strings.TrimPrefix(curVal, "@"); p != curVal && !strings.HasPrefix(p, "@") {
+		if p = ExpandConfigString(p); !filepath.IsAbs(p) && len(val.Source(name)) > 0 {
+			p = filepath.Join(filepath.Dir(val.Source(name)), p)
+		}
+		curVal = "@" + p
+	}
+	fld := c.Variable.(*string)
+	if *fld == c.Value {
+		if curVal, err = ReadSecret(curVal); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if c.debug {
+			log.Println("overriding " + c.Name + " with CONFIG variable setting")
+		}
+		*fld = curVal
+	}
+	return nil
+}
+func (c *SecretFlg) RequiredAndNotSet() bool {
+	fld := c.Variable.(*string)
+	// if this is the same it wasn't set
+	if c.Required && *fld == c.Value {
+		return true
+	}
+	return false
+}
+func (c *SecretFlg) GCommaSepVal() bool {
+	return false
+}
+func (c *SecretFlg) ValidValue() bool {
+	return true
+}
+
+// ValueAsString returns Redacted when a value is set, like GVariableToString
+func (c *SecretFlg) ValueAsString() string {
+	return c.GVariableToString()
+}
+
+// Kind check if this is NOT of type pointer or Nil and return error
+func (c *SecretFlg) Kind() error {
+	rv := reflect.ValueOf(c)
+	if rv.Kind() != reflect.Ptr {
+		name := rv.FieldByName("Name").String()
+		return &InvalidObjectError{reflect.TypeOf(c), "'" + name + "' flag of type"}
+	} else if rv.IsNil() {
+		return &InvalidObjectError{reflect.TypeOf(c), ""}
+	}
+	return nil
+}
+func (c *SecretFlg) GHidden() bool {
+	return c.Hidden
+}
+func (c *SecretFlg) GCategory() string {
+	return c.Category
+}
+func (c *SecretFlg) GComplete() CompleteFunc {
+	return c.Complete
+}
+func (c *SecretFlg) SetDebug(dbg bool) {
+	c.debug = dbg
+}
+func (c *SecretFlg) SetDebugLevel(lvl int64) {
+	c.debugLevel = lvl
+}
+func (c *SecretFlg) UnquotedUsage() string {
+	return "string"
+}
@@ -0,0 +1,86 @@
+package mycli
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func secretTestCli(token *string) *CLI {
+	c := NewCli(nil, nil)
+	c.TestMode = true
+	c.DisableEnvVars = false
+	c.MainAction = func() {}
+	c.Flgs = []CLIFlag{&SecretFlg{Variable: token, Name: "token", Usage: "API token", Value: "dflt-token"}}
+	return c
+}
+
+func TestSecretFlgSources(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	dir := t.TempDir()
+	file := writeConfig(t, dir, "token.txt", "from-file\n")
+	var token string
+	parse := func(args ...string) error {
+		ResetForTesting(nil)
+		cli = secretTestCli(&token)
+		os.Args = append([]string{"cmd"}, args...)
+		return cli.Parse()
+	}
+
+	assert.NoError(t, parse("-token", "from-cli"))
+	assert.Equal(t, "from-cli", token)
+	assert.NoError(t, parse("-token", "@"+file))
+	assert.Equal(t, "from-file", token)
+	assert.NoError(t, parse("-token", "@@literal"))
+	assert.Equal(t, "@literal", token)
+
+	os.Setenv("T_TOKEN_FILE", file)
+	defer os.Unsetenv("T_TOKEN_FILE")
+	assert.NoError(t, parse())
+	assert.Equal(t, "from-file", token)
+	os.Setenv("T_TOKEN", "from-env")
+	assert.NoError(t, parse())
+	assert.Equal(t, "from-env", token)
+	os.Unsetenv("T_TOKEN")
+	os.Setenv("T_TOKEN_FILE", file+".missing")
+	assert.EqualError(t, parse(), "T_TOKEN_FILE: open "+file+".missing: no such file or directory")
+	os.Unsetenv("T_TOKEN_FILE")
+
+	cfg := writeConfig(t, dir, "app.toml", "token = \"@token.txt\"\n")
+	assert.NoError(t, parse("-c", cfg))
+	assert.Equal(t, "from-file", token)
+}
+
+func TestSecretFlgRedacted(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	var token string
+	var out bytes.Buffer
+	cli = secretTestCli(&token)
+	cli.Writer = &out
+	os.Args = []string{"cmd", "-token", "s3cr3t", "-print-config"}
+	assert.NoError(t, cli.Parse())
+	assert.Equal(t, "s3cr3t", token)
+	assert.Contains(t, out.String(), "token = \"<redacted>\"\n")
+	assert.NotContains(t, out.String(), "s3cr3t")
+
+	f := cli.Flgs[len(cli.Flgs)-1]
+	assert.Equal(t, Redacted, f.GVariableToString())
+	assert.Equal(t, Redacted, f.ValueAsString())
+	assert.True(t, isSecret(f))
+
+	row := flagRow(f, "")
+	assert.Equal(t, []string{"API token", "T_TOKEN, T_TOKEN_FILE (as environment var)"}, row.desc)
+	token = ""
+	assert.Equal(t, "", f.GVariableToString())
+
+	var skel bytes.Buffer
+	assert.NoError(t, cli.WriteConfigSkeleton(&skel, "toml"))
+	assert.Contains(t, skel.String(), "# API token\n# env: T_TOKEN\ntoken = \"\"\n")
+	assert.NotContains(t, skel.String(), "dflt-token")
+}
@@ -4,7 +4,6 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"os"
 	"reflect"
 	"strings"
 )
@@ -24,6 +23,8 @@ type StringFlg struct {
 	Action        interface{}
 	Options       []string
 	Hidden        bool
+	Category      string
+	Complete      CompleteFunc
 	debug         bool
 	debugLevel    int64
 }
@@ -110,7 +111,7 @@ func (c *StringFlg) GOptions() interface{} {
 func (c *StringFlg) RetrieveEnvValue() error {
 	fld := c.Variable.(*string)
 	if *fld == c.Value {
-		if envVal, found := os.LookupEnv(c.EnvVar); found {
+		if envVal, found := LookupEnv(c.EnvVar); found {
 			if c.debug {
 				log.Println("overriding " + c.Name + " with env variable setting '" + envVal + "'")
 			}
@@ -125,7 +126,10 @@ func (c *StringFlg) RetrieveConfigValue(val *TomlWrapper, name string) error {
 	//if len(c.Command) == 0 {
 	//	name = c.Name
 	//}
-	curVal = val.Get(name).(string)
+	curVal, err := CoerceString(val.Get(name))
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
 	fld := c.Variable.(*string)
 	if *fld == c.Value {
 		if c.debug {
@@ -198,6 +202,12 @@ func (c *StringFlg) Kind() error {
 func (c *StringFlg) GHidden() bool {
 	return c.Hidden
 }
+func (c *StringFlg) GCategory() string {
+	return c.Category
+}
+func (c *StringFlg) GComplete() CompleteFunc {
+	return c.Complete
+}
 func (c *StringFlg) SetDebug(dbg bool) {
 	c.debug = dbg
 }
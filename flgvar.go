@@ -4,7 +4,6 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"os"
 	"reflect"
 	"strings"
 )
@@ -24,6 +23,9 @@ type VarFlg struct {
 	Action        interface{}
 	Options       []StringList
 	Hidden        bool
+	Category      string
+	Complete      CompleteFunc
+	Repeat        bool
 	debug         bool
 	debugLevel    int64
 }
@@ -41,11 +43,15 @@ func (c *VarFlg) AdjustValue(cmd string, flgValues map[string]interface{}) {
 func (c *VarFlg) BuildFlag(flgSet *flag.FlagSet, varMap map[string][]FieldPtr, flgValues map[string]interface{}) {
 	// obtain variable field pointer
 	fld := c.Variable.(*StringList)
+	var val flag.Value = fld
+	if c.Repeat {
+		val = &repeatValue{list: fld}
+	}
 	// set value to variable pointer using golang std lib with the passed in command line name
-	flgSet.Var(fld, c.Name, c.Usage)
+	flgSet.Var(val, c.Name, c.Usage)
 	if len(c.ShortName) > 0 {
 		// set value to variable using golang std lib with the passed in command line short name
-		flgSet.Var(fld, c.ShortName, c.Usage)
+		flgSet.Var(val, c.ShortName, c.Usage)
 	}
 	// set value to memory pointer of variable
 	*fld = c.Value
@@ -107,7 +113,7 @@ func (c *VarFlg) GOptions() interface{} {
 func (c *VarFlg) RetrieveEnvValue() error {
 	fld := c.Variable.(*StringList)
 	if reflect.DeepEqual(*fld, c.Value) {
-		if envVal, found := os.LookupEnv(c.EnvVar); found {
+		if envVal, found := LookupEnv(c.EnvVar); found {
 			if c.debug {
 				log.Println("overriding " + c.Name + " with env variable setting '" + envVal + "'")
 			}
@@ -125,7 +131,10 @@ func (c *VarFlg) RetrieveConfigValue(val *TomlWrapper, name string) error {
 	//if len(c.Command) == 0 {
 	//	name = c.Name
 	//}
-	curVal = val.Get(name).(StringList)
+	curVal, err := CoerceStringList(val.Get(name))
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
 	fld := c.Variable.(*StringList)
 	if fld.String() == c.Value.String() {
 		if c.debug {
@@ -196,6 +205,12 @@ func (c *VarFlg) Kind() error {
 func (c *VarFlg) GHidden() bool {
 	return c.Hidden
 }
+func (c *VarFlg) GCategory() string {
+	return c.Category
+}
+func (c *VarFlg) GComplete() CompleteFunc {
+	return c.Complete
+}
 func (c *VarFlg) SetDebug(dbg bool) {
 	c.debug = dbg
 }
@@ -222,3 +237,26 @@ func (s *StringList) Set(value string) error {
 func (c *StringList) UnquotedUsage() string {
 	return "string"
 }
+
+// repeatValue appends each use of a Repeat flag to the list as one entry, the first use replaces the default.
+// Values are not split on commas, so a path holding a comma can be passed.
+type repeatValue struct {
+	list *StringList
+	set  bool
+}
+
+func (r *repeatValue) String() string {
+	if r.list == nil {
+		return "[]"
+	}
+	return r.list.String()
+}
+
+func (r *repeatValue) Set(value string) error {
+	if !r.set {
+		*r.list = nil
+		r.set = true
+	}
+	*r.list = append(*r.list, value)
+	return nil
+}
@@ -0,0 +1,332 @@
+package mycli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GenerateManPages writes <app>.1 into dir and, when perCommand is set, one <app>-<command>.1 page
+// per visible command and subcommand. It returns the paths written.
+func (c *CLI) GenerateManPages(dir string, perCommand bool) ([]string, error) {
+	c.prepare()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	name := c.appName()
+	written := make([]string, 0)
+	// the pages this run writes, the only ones SEE ALSO may name
+	pages := map[string]bool{name: true}
+	if perCommand {
+		walkCommands(c.Cmds, nil, func(path []*CLICommand) {
+			pages[manPageName(name, path)] = true
+		})
+	}
+
+	var byt bytes.Buffer
+	if err := c.writeManPage(&byt, pages); err != nil {
+		return written, err
+	}
+	pth := filepath.Join(dir, name+".1")
+	if err := os.WriteFile(pth, byt.Bytes(), 0644); err != nil {
+		return written, err
+	}
+	written = append(written, pth)
+
+	if !perCommand {
+		return written, nil
+	}
+	var err error
+	walkCommands(c.Cmds, nil, func(path []*CLICommand) {
+		if err != nil {
+			return
+		}
+		byt.Reset()
+		if err = c.writeCommandManPage(&byt, path, pages); err != nil {
+			return
+		}
+		pth := filepath.Join(dir, manPageName(name, path)+".1")
+		if err = os.WriteFile(pth, byt.Bytes(), 0644); err != nil {
+			return
+		}
+		written = append(written, pth)
+	})
+	return written, err
+}
+
+// WriteManPage writes the roff man page of the whole application, global options and every visible command.
+// SEE ALSO is left out, the other pages are only known to exist when GenerateManPages writes them.
+func (c *CLI) WriteManPage(w io.Writer) error {
+	c.prepare()
+	return c.writeManPage(w, nil)
+}
+
+// writeManPage writes the application page, SEE ALSO names the command pages in pages
+func (c *CLI) writeManPage(w io.Writer, pages map[string]bool) error {
+	name := c.appName()
+
+	var byt bytes.Buffer
+	c.manHeader(&byt, name)
+	byt.WriteString(".SH NAME\n")
+	byt.WriteString(roffEscape(name))
+	if len(c.Title) > 0 {
+		byt.WriteString(" \\- " + roffEscape(c.Title))
+	}
+	byt.WriteString("\n")
+
+	byt.WriteString(".SH SYNOPSIS\n")
+	if len(c.AppInfo.Usage) > 0 {
+		byt.WriteString(roffText(c.AppInfo.Usage) + "\n")
+	} else {
+		byt.WriteString(".B " + roffEscape(name) + "\n")
+		byt.WriteString("[global options] command [command options] [arguments...]\n")
+	}
+	if len(c.Description) > 0 {
+		byt.WriteString(".SH DESCRIPTION\n")
+		byt.WriteString(roffText(c.Description) + "\n")
+	}
+
+	if flgs := visibleFlags(c.Flgs); len(flgs) > 0 {
+		byt.WriteString(".SH GLOBAL OPTIONS\n")
+		writeManFlags(&byt, flgs)
+	}
+
+	examples := make([]Example, 0)
+	seeAlso := make([]string, 0)
+	wroteHeader := false
+	walkCommands(c.Cmds, nil, func(path []*CLICommand) {
+		if !wroteHeader {
+			byt.WriteString(".SH COMMANDS\n")
+			wroteHeader = true
+		}
+		cmd := path[len(path)-1]
+		byt.WriteString(".SS " + roffEscape(commandPath(path)) + "\n")
+		if len(cmd.ShortName) > 0 {
+			byt.WriteString("Alias: " + roffEscape(cmd.ShortName) + "\n.br\n")
+		}
+		if len(cmd.Usage) > 0 {
+			byt.WriteString(roffText(cmd.Usage) + "\n")
+		}
+		writeManFlags(&byt, visibleFlags(cmd.Flags))
+		examples = append(examples, cmd.Examples...)
+		seeAlso = append(seeAlso, manPageName(name, path))
+	})
+
+	if len(examples) > 0 {
+		byt.WriteString(".SH EXAMPLES\n")
+		writeManExamples(&byt, examples)
+	}
+	c.manFooter(&byt, seeAlso, pages)
+
+	_, err := w.Write(byt.Bytes())
+	return err
+}
+
+// WriteCommandManPage writes the roff man page for a single command, path names the command and optional
+// subcommand. Like WriteManPage it has no SEE ALSO.
+func (c *CLI) WriteCommandManPage(w io.Writer, path ...string) error {
+	c.prepare()
+	cmd, parent, rest := c.resolveCommand(path)
+	if cmd == nil || len(rest) > 0 {
+		return fmt.Errorf("unknown command '%s'", strings.Join(path, " "))
+	}
+	cmds := []*CLICommand{cmd}
+	if parent != nil {
+		cmds = []*CLICommand{parent, cmd}
+	}
+	return c.writeCommandManPage(w, cmds, nil)
+}
+
+func (c *CLI) writeCommandManPage(w io.Writer, path []*CLICommand, pages map[string]bool) error {
+	name := c.appName()
+	cmd := path[len(path)-1]
+	page := manPageName(name, path)
+
+	var byt bytes.Buffer
+	c.manHeader(&byt, page)
+	byt.WriteString(".SH NAME\n")
+	byt.WriteString(roffEscape(page))
+	if len(cmd.Usage) > 0 {
+		byt.WriteString(" \\- " + roffEscape(firstLine(cmd.Usage)))
+	}
+	byt.WriteString("\n")
+
+	byt.WriteString(".SH SYNOPSIS\n")
+	byt.WriteString(".B " + roffEscape(name+" "+commandPath(path)) + "\n")
+	if len(cmd.SubCommands) > 0 {
+		byt.WriteString("[subcommand] ")
+	}
+	byt.WriteString("[command options] [arguments...]\n")
+	if len(cmd.Usage) > 0 {
+		byt.WriteString(".SH DESCRIPTION\n")
+		byt.WriteString(roffText(cmd.Usage) + "\n")
+	}
+	if len(cmd.ShortName) > 0 {
+		byt.WriteString(".PP\nAlias: " + roffEscape(cmd.ShortName) + "\n")
+	}
+
+	if flgs := visibleFlags(cmd.Flags); len(flgs) > 0 {
+		byt.WriteString(".SH OPTIONS\n")
+		writeManFlags(&byt, flgs)
+	}
+
+	seeAlso := []string{name}
+	subs := make([]*CLICommand, 0)
+	for _, k := range cmd.SubCommands {
+		if !k.Hidden {
+			subs = append(subs, k)
+		}
+	}
+	if len(subs) > 0 {
+		byt.WriteString(".SH SUBCOMMANDS\n")
+		for _, k := range subs {
+			byt.WriteString(".TP\n.B " + roffEscape(k.Name) + "\n")
+			byt.WriteString(roffText(k.Usage) + "\n")
+			seeAlso = append(seeAlso, manPageName(name, append(path, k)))
+		}
+	}
+	if len(cmd.Examples) > 0 {
+		byt.WriteString(".SH EXAMPLES\n")
+		writeManExamples(&byt, cmd.Examples)
+	}
+	c.manFooter(&byt, seeAlso, pages)
+
+	_, err := w.Write(byt.Bytes())
+	return err
+}
+
+// manHeader writes the .TH title line, the version and title are taken from AppInfo.
+func (c *CLI) manHeader(byt *bytes.Buffer, page string) {
+	source := c.appName()
+	if len(c.Version) > 0 {
+		source += " " + c.Version
+	}
+	fmt.Fprintf(byt, ".TH %q 1 %q %q %q\n", strings.ToUpper(page), c.BuildDate, source, c.Title)
+}
+
+// manFooter writes AUTHOR, COPYRIGHT and SEE ALSO sections, SEE ALSO names the pages of seeAlso found in pages
+func (c *CLI) manFooter(byt *bytes.Buffer, seeAlso []string, pages map[string]bool) {
+	if len(c.Author) > 0 {
+		byt.WriteString(".SH AUTHOR\n" + roffText(c.Author) + "\n")
+	}
+	if len(c.Copyright) > 0 {
+		byt.WriteString(".SH COPYRIGHT\n" + roffText(c.Copyright) + "\n")
+	}
+	refs := make([]string, 0, len(seeAlso))
+	for _, s := range seeAlso {
+		if pages[s] {
+			refs = append(refs, ".BR "+roffEscape(s)+" (1)")
+		}
+	}
+	if len(refs) > 0 {
+		byt.WriteString(".SH SEE ALSO\n" + strings.Join(refs, " ,\n") + "\n")
+	}
+}
+
+// writeManFlags writes one tagged paragraph per flag with its usage, default, options, env var and required marker.
+func writeManFlags(byt *bytes.Buffer, flgs []CLIFlag) {
+	for _, f := range flgs {
+		byt.WriteString(".TP\n")
+		byt.WriteString("\\fB\\-" + roffEscape(f.GName()) + "\\fR")
+		if len(strings.TrimSpace(f.GShortName())) > 0 {
+			byt.WriteString(", \\fB\\-" + roffEscape(f.GShortName()) + "\\fR")
+		}
+		if typeName := f.UnquotedUsage(); len(typeName) > 0 {
+			byt.WriteString(" \\fI" + roffEscape(typeName) + "\\fR")
+		}
+		byt.WriteString("\n")
+		lines := make([]string, 0)
+		if len(f.GUsage()) > 0 {
+			lines = append(lines, roffText(f.GUsage()))
+		}
+		if f.GRequired() {
+			lines = append(lines, "Required.")
+		}
+		if tmp := defaultText(f); len(tmp) > 0 {
+			lines = append(lines, "Default: "+roffEscape(tmp))
+		}
+		if tmp := optionsText(f); len(tmp) > 0 {
+			lines = append(lines, "Options: "+roffEscape(tmp))
+		}
+		if len(f.GEnvVar()) > 0 {
+			lines = append(lines, "Environment: \\fB"+roffEscape(f.GEnvVar())+"\\fR")
+		}
+		byt.WriteString(strings.Join(lines, "\n.br\n") + "\n")
+	}
+}
+
+func writeManExamples(byt *bytes.Buffer, examples []Example) {
+	for _, e := range examples {
+		if len(e.Description) > 0 {
+			byt.WriteString(".PP\n" + roffText(e.Description) + "\n")
+		}
+		byt.WriteString(".PP\n.RS 4\n.nf\n" + roffEscape(e.Cmd) + "\n.fi\n.RE\n")
+	}
+}
+
+// walkCommands calls fn with the path to every visible command and subcommand, parents first.
+func walkCommands(cmds []*CLICommand, parents []*CLICommand, fn func(path []*CLICommand)) {
+	for _, d := range cmds {
+		if d.Hidden {
+			continue
+		}
+		path := append(append([]*CLICommand{}, parents...), d)
+		fn(path)
+		walkCommands(d.SubCommands, path, fn)
+	}
+}
+
+// visibleFlags drops hidden flags.
+func visibleFlags(flgs []CLIFlag) []CLIFlag {
+	tmp := make([]CLIFlag, 0, len(flgs))
+	for _, f := range flgs {
+		if !f.GHidden() {
+			tmp = append(tmp, f)
+		}
+	}
+	return tmp
+}
+
+// commandPath joins command names as typed on the command line, i.e. "weserve config".
+func commandPath(path []*CLICommand) string {
+	names := make([]string, 0, len(path))
+	for _, p := range path {
+		names = append(names, strings.ToLower(p.Name))
+	}
+	return strings.Join(names, " ")
+}
+
+// manPageName names a command page like git does, i.e. "myapp-weserve-config".
+func manPageName(app string, path []*CLICommand) string {
+	return app + "-" + strings.Replace(commandPath(path), " ", "-", -1)
+}
+
+func firstLine(s string) string {
+	if idx := strings.Index(s, "\n"); idx > -1 {
+		return s[:idx]
+	}
+	return s
+}
+
+// roffEscape escapes backslashes and dashes so roff prints them literally.
+func roffEscape(s string) string {
+	s = strings.Replace(s, "\\", "\\e", -1)
+	return strings.Replace(s, "-", "\\-", -1)
+}
+
+// roffText escapes text and protects lines starting with a control character.
+func roffText(s string) string {
+	lines := strings.Split(roffEscape(s), "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+			lines[i] = "\\&" + l
+		}
+		if len(strings.TrimSpace(l)) == 0 {
+			lines[i] = ".PP"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
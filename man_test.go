@@ -0,0 +1,103 @@
+package mycli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func manTestCli() *CLI {
+	var (
+		port    int64
+		app     string
+		capture string
+	)
+	c := NewCli(nil, nil)
+	c.Title = "Demo"
+	c.Description = "Demo application"
+	c.Author = "Jane Doe"
+	c.DisableEnvVars = false
+	c.Flgs = []CLIFlag{
+		&StringFlg{Variable: &capture, Name: "capture", ShortName: "cap", Usage: "capture mode", Value: "hello", Options: []string{"hello", "bye"}},
+	}
+	c.Cmds = []*CLICommand{
+		{
+			Name:  "weserve",
+			Usage: "serve things",
+			SubCommands: []*CLICommand{
+				{
+					Name:     "config",
+					Usage:    "use config file",
+					Action:   func() {},
+					Examples: []Example{{Cmd: "app weserve config -port 9000", Description: "serve on 9000"}},
+					Flags: []CLIFlag{
+						&Int64Flg{Variable: &port, Name: "port", Usage: "Set Port", Value: 9111},
+						&StringFlg{Variable: &app, Name: "application", Usage: "application name", Required: true},
+					},
+				},
+			},
+		},
+		{Name: "secret", Usage: "hidden diagnostics", Hidden: true, Action: func() {}},
+	}
+	return c
+}
+
+func TestWriteManPage(t *testing.T) {
+	c := manTestCli()
+
+	var byt bytes.Buffer
+	err := c.WriteManPage(&byt)
+	assert.NoError(t, err)
+
+	page := byt.String()
+	assert.Contains(t, page, ".SH NAME\n")
+	assert.Contains(t, page, "\\fB\\-capture\\fR, \\fB\\-cap\\fR \\fIstring\\fR\ncapture mode\n.br\nDefault: hello\n.br\nOptions: [hello bye]\n.br\nEnvironment: \\fBT_CAPTURE\\fR")
+	assert.Contains(t, page, ".SS weserve config\n")
+	assert.Contains(t, page, "Required.")
+	assert.Contains(t, page, "app weserve config \\-port 9000")
+	assert.Contains(t, page, ".SH AUTHOR\nJane Doe")
+	assert.NotContains(t, page, "hidden diagnostics")
+	// the other pages may not exist
+	assert.NotContains(t, page, ".SH SEE ALSO")
+}
+
+func TestGenerateManPages(t *testing.T) {
+	c := manTestCli()
+	dir := t.TempDir()
+
+	written, err := c.GenerateManPages(dir, true)
+	assert.NoError(t, err)
+
+	name := c.appName()
+	assert.Contains(t, written, filepath.Join(dir, name+".1"))
+	assert.Contains(t, written, filepath.Join(dir, name+"-weserve-config.1"))
+	assert.NotContains(t, written, filepath.Join(dir, name+"-secret.1"))
+
+	page, err := os.ReadFile(filepath.Join(dir, name+"-weserve-config.1"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(page), ".SH OPTIONS\n")
+	assert.Contains(t, string(page), ".BR "+roffEscape(name)+" (1)")
+
+	// SEE ALSO only names pages that were written
+	seeAlso := regexp.MustCompile(`\.BR (\S+) \(1\)`)
+	for _, pth := range written {
+		page, err := os.ReadFile(pth)
+		assert.NoError(t, err)
+		for _, m := range seeAlso.FindAllStringSubmatch(string(page), -1) {
+			assert.FileExists(t, filepath.Join(dir, strings.ReplaceAll(m[1], "\\-", "-")+".1"), pth)
+		}
+	}
+
+	dir = t.TempDir()
+	written, err = c.GenerateManPages(dir, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, name+".1")}, written)
+	page, err = os.ReadFile(written[0])
+	assert.NoError(t, err)
+	assert.NotContains(t, string(page), ".SH SEE ALSO")
+}
@@ -0,0 +1,429 @@
+package mycli
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultHelpWidth used when neither HelpWidth nor COLUMNS is set
+	defaultHelpWidth = 80
+	// minDescWidth keeps room for descriptions when flag names are long
+	minDescWidth = 24
+)
+
+// helpRow is a single help entry, a name column and the description paragraphs beside it.
+type helpRow struct {
+	name string
+	desc []string
+}
+
+// helpWidth returns the column help output wraps at.
+func (c *CLI) helpWidth() int {
+	if c.HelpWidth > 0 {
+		return c.HelpWidth
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	return defaultHelpWidth
+}
+
+// printUsage writes the global help to the CLI Writer.
+func (c *CLI) printUsage() {
+	flag.Usage = func() {
+		fmt.Fprint(c.Writer, c.usageText())
+	}
+	flag.Usage()
+}
+
+// usageText renders the global help, header, global options, commands and footer.
+func (c *CLI) usageText() string {
+	width := c.helpWidth()
+	name := c.appName()
+
+	var byt bytes.Buffer
+	byt.WriteString("NAME:\n")
+	if len(c.Title) > 0 {
+		writeIndented(&byt, name+" - "+c.Title, "  ", width)
+	} else {
+		writeIndented(&byt, name, "  ", width)
+	}
+	byt.WriteString("\n")
+
+	byt.WriteString("USAGE:\n")
+	if len(c.AppInfo.Usage) > 0 {
+		writeIndented(&byt, c.AppInfo.Usage, "  ", width)
+	} else {
+		writeIndented(&byt, fmt.Sprintf("%s [global options] command [command options] [arguments...]", name), "  ", width)
+	}
+	byt.WriteString("\n")
+
+	if len(c.Description) > 0 {
+		byt.WriteString("DESCRIPTION:\n")
+		writeIndented(&byt, c.Description, "  ", width)
+		byt.WriteString("\n")
+	}
+
+	globals := c.categorizedFlagRows(c.Flgs, "  ")
+	commands := c.categorizedCommandRows(c.Cmds, "  ")
+	col := helpColumn(width, globals, commands)
+
+	if len(globals) > 0 {
+		byt.WriteString("GLOBAL OPTIONS:\n")
+		writeRows(&byt, globals, col, width)
+		byt.WriteString("\n")
+	}
+	if len(commands) > 0 {
+		byt.WriteString("COMMANDS:\n")
+		writeRows(&byt, commands, col, width)
+		byt.WriteString("\n")
+	}
+
+	if len(c.Author) > 0 {
+		byt.WriteString("AUTHOR:\n")
+		writeIndented(&byt, c.Author, "  ", width)
+		byt.WriteString("\n")
+	}
+	if len(c.Copyright) > 0 {
+		byt.WriteString("COPYRIGHT:\n")
+		writeIndented(&byt, c.Copyright, "  ", width)
+		byt.WriteString("\n")
+	}
+	return byt.String()
+}
+
+// flagSetUsage is set as the FlagSet usage of the active command.
+func (c *CLI) flagSetUsage() {
+	fmt.Fprint(c.Writer, c.commandUsageText(c.cur))
+}
+
+// commandUsageText renders the help for a single command or subcommand.
+func (c *CLI) commandUsageText(cmd *CLICommand) string {
+	width := c.helpWidth()
+
+	var byt bytes.Buffer
+	byt.WriteString("Usage of " + cmd.Name + ":\n")
+	if len(cmd.Usage) > 0 {
+		writeIndented(&byt, cmd.Usage, "  ", width)
+	}
+
+	// sort a copy so the command definition keeps its order
+	subcmds := make([]*CLICommand, 0, len(cmd.SubCommands))
+	for _, sc := range cmd.SubCommands {
+		if !sc.Hidden || c.helpAll {
+			subcmds = append(subcmds, sc)
+		}
+	}
+	sort.Slice(subcmds, func(i, j int) bool {
+		return subcmds[i].Name < subcmds[j].Name
+	})
+	subRows := make([]helpRow, 0, len(subcmds))
+	for _, sc := range subcmds {
+		subRows = append(subRows, helpRow{name: "    " + commandName(sc), desc: []string{hiddenNote(sc.Usage, sc.Hidden)}})
+	}
+	flgRows := c.categorizedFlagRows(cmd.Flags, "    ")
+	col := helpColumn(width, subRows, flgRows)
+
+	if len(subRows) > 0 {
+		byt.WriteString("\n  Sub Commands:\n")
+		writeRows(&byt, subRows, col, width)
+	}
+	if len(flgRows) > 0 {
+		byt.WriteString("\n  Options:\n")
+		writeRows(&byt, flgRows, col, width)
+	}
+	writeExamples(&byt, cmd.Examples, "  ")
+	byt.WriteString("\n")
+	return byt.String()
+}
+
+// commandRows lists a command with its flags and visible subcommands for the global help.
+func (c *CLI) commandRows(d *CLICommand, indent string) []helpRow {
+	rows := []helpRow{{name: indent + commandName(d), desc: []string{hiddenNote(strings.ToLower(d.Usage), d.Hidden)}}}
+	rows = append(rows, c.categorizedFlagRows(d.Flags, indent+"    ")...)
+
+	header := false
+	for _, k := range d.SubCommands {
+		if k.Hidden && !c.helpAll {
+			continue
+		}
+		if !header {
+			rows = append(rows, helpRow{name: indent + "  Sub Commands:"})
+			header = true
+		}
+		rows = append(rows, helpRow{name: indent + "    " + commandName(k), desc: []string{hiddenNote(strings.ToLower(k.Usage), k.Hidden)}})
+		rows = append(rows, c.categorizedFlagRows(k.Flags, indent+"      ")...)
+	}
+	return rows
+}
+
+// categorizedCommandRows lists visible commands, uncategorized first and then one heading per category.
+func (c *CLI) categorizedCommandRows(cmds []*CLICommand, indent string) []helpRow {
+	groups := make(map[string][]*CLICommand)
+	found := make([]string, 0)
+	for _, d := range cmds {
+		if d.Hidden && !c.helpAll {
+			continue
+		}
+		if _, ok := groups[d.Category]; !ok && len(d.Category) > 0 {
+			found = append(found, d.Category)
+		}
+		groups[d.Category] = append(groups[d.Category], d)
+	}
+
+	rows := make([]helpRow, 0)
+	add := func(list []*CLICommand, indent string) {
+		for i, d := range list {
+			if i > 0 {
+				// blank line between commands
+				rows = append(rows, helpRow{})
+			}
+			rows = append(rows, c.commandRows(d, indent)...)
+		}
+	}
+	add(groups[""], indent)
+	for _, cat := range c.categoryOrder(found) {
+		if len(rows) > 0 {
+			rows = append(rows, helpRow{})
+		}
+		rows = append(rows, helpRow{name: indent + cat + ":"})
+		add(groups[cat], indent+"  ")
+	}
+	return rows
+}
+
+// categorizedFlagRows lists visible flags, uncategorized first and then one heading per category.
+func (c *CLI) categorizedFlagRows(flgs []CLIFlag, indent string) []helpRow {
+	groups := make(map[string][]CLIFlag)
+	found := make([]string, 0)
+	for _, f := range flgs {
+		if _, ok := groups[f.GCategory()]; !ok && len(f.GCategory()) > 0 {
+			found = append(found, f.GCategory())
+		}
+		groups[f.GCategory()] = append(groups[f.GCategory()], f)
+	}
+
+	rows := c.flagRows(groups[""], indent)
+	for _, cat := range c.categoryOrder(found) {
+		catRows := c.flagRows(groups[cat], indent+"  ")
+		if len(catRows) == 0 {
+			continue
+		}
+		if len(rows) > 0 {
+			rows = append(rows, helpRow{})
+		}
+		rows = append(rows, helpRow{name: indent + cat + ":"})
+		rows = append(rows, catRows...)
+	}
+	return rows
+}
+
+// categoryOrder sorts found categories by CLI.Categories, unlisted ones keep their order of appearance.
+func (c *CLI) categoryOrder(found []string) []string {
+	ordered := make([]string, 0, len(found))
+	for _, cat := range c.Categories {
+		for _, f := range found {
+			if f == cat {
+				ordered = append(ordered, cat)
+				break
+			}
+		}
+	}
+	for _, f := range found {
+		listed := false
+		for _, cat := range ordered {
+			if f == cat {
+				listed = true
+				break
+			}
+		}
+		if !listed {
+			ordered = append(ordered, f)
+		}
+	}
+	return ordered
+}
+
+// flagRows builds one help row per visible flag, hidden flags are included with --help-all.
+func (c *CLI) flagRows(flgs []CLIFlag, indent string) []helpRow {
+	rows := make([]helpRow, 0, len(flgs))
+	for _, f := range flgs {
+		if f.GHidden() && !c.helpAll {
+			continue
+		}
+		rows = append(rows, flagRow(f, indent))
+	}
+	return rows
+}
+
+// flagRow renders the name column as "-name, -short type" and the usage, default, options and env var as description.
+func flagRow(f CLIFlag, indent string) helpRow {
+	name := indent + "-" + f.GName()
+	if len(strings.TrimSpace(f.GShortName())) > 0 {
+		name += ", -" + f.GShortName()
+	}
+	if typeName := f.UnquotedUsage(); len(typeName) > 0 {
+		name += " " + typeName
+	}
+
+	usage := f.GUsage()
+	if tmp := defaultText(f); len(tmp) > 0 {
+		usage += " (default " + tmp + ")"
+	}
+	if f.GRequired() {
+		usage += " (REQUIRED_FLAG)"
+	}
+	usage = hiddenNote(usage, f.GHidden())
+	desc := []string{strings.TrimSpace(usage)}
+	if tmp := optionsText(f); len(tmp) > 0 {
+		desc = append(desc, "Options: "+tmp)
+	}
+	if len(f.GEnvVar()) > 0 {
+		env := f.GEnvVar()
+		if _, ok := f.(*SecretFlg); ok {
+			env += ", " + f.GEnvVar() + "_FILE"
+		}
+		desc = append(desc, env+" (as environment var)")
+	}
+	return helpRow{name: name, desc: desc}
+}
+
+// defaultText formats the default value of a flag, empty when there is nothing to show or it is a secret.
+func defaultText(f CLIFlag) string {
+	if _, ok := f.(*SecretFlg); ok {
+		return ""
+	}
+	if l, ok := f.GValue().(StringList); ok && len(l) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%v", f.GValue())
+}
+
+// optionsText formats the Options of a flag, empty when none are set.
+func optionsText(f CLIFlag) string {
+	if tmp := fmt.Sprintf("%v", f.GOptions()); len(tmp) > 2 {
+		return tmp
+	}
+	return ""
+}
+
+// hiddenNote marks usage of hidden entries, which are only listed with --help-all.
+func hiddenNote(usage string, hidden bool) string {
+	if hidden {
+		return strings.TrimSpace(usage + " (hidden)")
+	}
+	return usage
+}
+
+// commandName shows a command as "name, short".
+func commandName(cmd *CLICommand) string {
+	if len(strings.TrimSpace(cmd.ShortName)) > 0 {
+		return strings.ToLower(cmd.Name) + ", " + strings.ToLower(cmd.ShortName)
+	}
+	return strings.ToLower(cmd.Name)
+}
+
+// helpColumn returns where descriptions start, two spaces past the longest name,
+// limited so descriptions keep at least minDescWidth columns.
+func helpColumn(width int, groups ...[]helpRow) int {
+	col := 0
+	for _, rows := range groups {
+		for _, r := range rows {
+			if len(r.desc) > 0 && len(r.name)+2 > col {
+				col = len(r.name) + 2
+			}
+		}
+	}
+	if col > width-minDescWidth {
+		col = width - minDescWidth
+	}
+	if col < 0 {
+		col = 0
+	}
+	return col
+}
+
+// writeRows writes rows as two columns, names that do not fit put their description on the next line.
+func writeRows(byt *bytes.Buffer, rows []helpRow, col, width int) {
+	descWidth := width - col
+	if descWidth < minDescWidth {
+		descWidth = minDescWidth
+	}
+	pad := strings.Repeat(" ", col)
+	for _, r := range rows {
+		byt.WriteString(r.name)
+		lines := make([]string, 0)
+		for _, d := range r.desc {
+			if len(d) == 0 {
+				continue
+			}
+			lines = append(lines, wrapText(d, descWidth)...)
+		}
+		if len(lines) == 0 {
+			byt.WriteString("\n")
+			continue
+		}
+		if len(r.name)+2 > col {
+			byt.WriteString("\n" + pad)
+		} else {
+			byt.WriteString(strings.Repeat(" ", col-len(r.name)))
+		}
+		byt.WriteString(strings.Join(lines, "\n"+pad))
+		byt.WriteString("\n")
+	}
+}
+
+// writeIndented wraps text to the width and prefixes every line with indent.
+func writeIndented(byt *bytes.Buffer, text, indent string, width int) {
+	w := width - len(indent)
+	if w < minDescWidth {
+		w = minDescWidth
+	}
+	for _, l := range wrapText(text, w) {
+		byt.WriteString(strings.TrimRight(indent+l, " ") + "\n")
+	}
+}
+
+// wrapText breaks text into lines of at most width on word boundaries,
+// explicit newlines are kept and words longer than width get a line of their own.
+func wrapText(text string, width int) []string {
+	lines := make([]string, 0)
+	for _, para := range strings.Split(text, "\n") {
+		words := strings.Fields(para)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		line := words[0]
+		for _, w := range words[1:] {
+			if len(line)+1+len(w) > width {
+				lines = append(lines, line)
+				line = w
+				continue
+			}
+			line += " " + w
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// writeExamples renders command examples, each description as a comment above its command line.
+func writeExamples(byt *bytes.Buffer, examples []Example, indent string) {
+	if len(examples) == 0 {
+		return
+	}
+	byt.WriteString("\n" + indent + "Examples:\n")
+	for _, e := range examples {
+		if len(e.Description) > 0 {
+			byt.WriteString(indent + "  # " + e.Description + "\n")
+		}
+		byt.WriteString(indent + "  " + e.Cmd + "\n")
+	}
+}
@@ -0,0 +1,328 @@
+package mycli
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapText(t *testing.T) {
+	cases := []struct {
+		name  string
+		text  string
+		width int
+		want  []string
+	}{
+		{"fits", "short text", 20, []string{"short text"}},
+		{"wraps", "one two three four", 9, []string{"one two", "three", "four"}},
+		{"long word", "a reallylongword b", 5, []string{"a", "reallylongword", "b"}},
+		{"newlines", "first line\nsecond", 20, []string{"first line", "second"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, wrapText(tc.text, tc.width))
+		})
+	}
+}
+
+func TestWriteExamples(t *testing.T) {
+	var byt bytes.Buffer
+	writeExamples(&byt, []Example{
+		{Cmd: "app server -port 9090", Description: "serve on 9090"},
+		{Cmd: "app server"},
+	}, "  ")
+
+	assert.Equal(t, "\n  Examples:\n    # serve on 9090\n    app server -port 9090\n    app server\n", byt.String())
+
+	byt.Reset()
+	writeExamples(&byt, nil, "  ")
+	assert.Equal(t, "", byt.String())
+}
+
+func TestUsageTextLayout(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	var (
+		protocol string
+		port     int64
+		out      bytes.Buffer
+	)
+	cli = NewCli(nil, nil)
+	cli.TestMode = true
+	cli.Writer = &out
+	cli.HelpWidth = 60
+	cli.Title = "Demo"
+	cli.Description = "Demo application"
+	cli.Author = "Jane Doe"
+	cli.Copyright = "(c) 2024 Example"
+	cli.Flgs = []CLIFlag{
+		&StringFlg{Variable: &protocol, Name: "protocol", ShortName: "proto", Usage: "protocol used to reach the remote server when it is not set in the config file", Value: "http"},
+	}
+	cli.Cmds = []*CLICommand{
+		{
+			Name:   "server",
+			Usage:  "use as a server",
+			Action: func() {},
+			Flags: []CLIFlag{
+				&Int64Flg{Variable: &port, Name: "port", ShortName: "p", Usage: "server port", Value: 8080},
+			},
+		},
+	}
+
+	os.Args = []string{"cmd", "-h"}
+	cli.Parse()
+
+	help := out.String()
+	assert.Contains(t, help, "cmd - Demo")
+	assert.Contains(t, help, "DESCRIPTION:\n  Demo application")
+	assert.Contains(t, help, "AUTHOR:\n  Jane Doe")
+	assert.Contains(t, help, "COPYRIGHT:\n  (c) 2024 Example")
+	for _, l := range strings.Split(help, "\n") {
+		assert.LessOrEqual(t, len(l), 60, l)
+	}
+	// descriptions share one column computed from the longest flag name
+	col := strings.Index(help, "protocol used")
+	lineStart := strings.LastIndex(help[:col], "\n") + 1
+	portCol := strings.Index(help, "server port")
+	portStart := strings.LastIndex(help[:portCol], "\n") + 1
+	assert.Equal(t, col-lineStart, portCol-portStart)
+}
+
+func TestUsageTextCategories(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	var (
+		format string
+		out    bytes.Buffer
+	)
+	cli = NewCli(nil, nil)
+	cli.TestMode = true
+	cli.Writer = &out
+	cli.Categories = []string{CategoryOutput, CategoryDebugging}
+	cli.Flgs = []CLIFlag{
+		&StringFlg{Variable: &format, Name: "format", Usage: "output format", Category: CategoryOutput},
+	}
+	cli.Cmds = []*CLICommand{
+		{Name: "serve", Usage: "start server", Action: func() {}},
+		{Name: "ping", Usage: "check a host", Category: "Diagnostics", Action: func() {}},
+	}
+
+	os.Args = []string{"cmd", "-h"}
+	cli.Parse()
+
+	help := out.String()
+	output := strings.Index(help, "  Output:\n    -format")
+	debugging := strings.Index(help, "  Debugging:\n    -debug")
+	networking := strings.Index(help, "  Networking:\n    -proxyhttp")
+	assert.True(t, output > 0 && debugging > output && networking > debugging, help)
+	assert.Less(t, strings.Index(help, "  serve"), strings.Index(help, "  Diagnostics:\n    ping"))
+}
+
+func TestHelpCommand(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	var (
+		port int64
+		out  bytes.Buffer
+	)
+	cli = NewCli(nil, nil)
+	cli.TestMode = true
+	cli.Writer = &out
+	cli.Cmds = []*CLICommand{
+		{
+			Name:  "weserve",
+			Usage: "serve things",
+			SubCommands: []*CLICommand{
+				{
+					Name:   "config",
+					Usage:  "use config file",
+					Action: func() {},
+					Flags: []CLIFlag{
+						&Int64Flg{Variable: &port, Name: "port", Usage: "Set Port", Value: 9111},
+					},
+				},
+			},
+		},
+	}
+
+	os.Args = []string{"cmd", "help", "weserve", "config"}
+	err := cli.Parse()
+
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "Usage of config:")
+	assert.Contains(t, out.String(), "-port int")
+
+	os.Args = []string{"cmd", "help", "weserve", "nope"}
+	ResetForTesting(nil)
+	err = cli.Parse()
+	assert.EqualError(t, err, "unknown command 'weserve nope'")
+}
+
+func TestHelpAll(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	var (
+		trace bool
+		out   bytes.Buffer
+	)
+	cli = NewCli(nil, nil)
+	cli.TestMode = true
+	cli.Writer = &out
+	cli.Flgs = []CLIFlag{
+		&BoolFlg{Variable: &trace, Name: "trace", Usage: "dump diagnostics", Hidden: true},
+	}
+	cli.Cmds = []*CLICommand{
+		{Name: "diag", Usage: "diagnostics", Hidden: true, Action: func() {}},
+	}
+
+	os.Args = []string{"cmd", "-h"}
+	cli.Parse()
+	assert.NotContains(t, out.String(), "-trace")
+	assert.NotContains(t, out.String(), "diag")
+
+	out.Reset()
+	ResetForTesting(nil)
+	os.Args = []string{"cmd", "--help-all"}
+	cli.Parse()
+	assert.Contains(t, out.String(), "-trace")
+	assert.Contains(t, out.String(), "dump diagnostics (default false) (hidden)")
+	assert.Contains(t, out.String(), "diag")
+}
+
+func TestHelpAllHelpCommand(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	var (
+		trace bool
+		out   bytes.Buffer
+	)
+	for _, args := range [][]string{
+		{"cmd", "--help-all", "help", "weserve"},
+		{"cmd", "help", "--help-all", "weserve"},
+	} {
+		out.Reset()
+		ResetForTesting(nil)
+		cli = NewCli(nil, nil)
+		cli.TestMode = true
+		cli.Writer = &out
+		cli.Cmds = []*CLICommand{
+			{
+				Name:   "weserve",
+				Usage:  "serve things",
+				Action: func() {},
+				Flags: []CLIFlag{
+					&BoolFlg{Variable: &trace, Name: "trace", Usage: "dump diagnostics", Hidden: true},
+				},
+				SubCommands: []*CLICommand{{Name: "diag", Usage: "diagnostics", Hidden: true, Action: func() {}}},
+			},
+		}
+		os.Args = args
+		assert.NoError(t, cli.Parse(), args)
+		assert.Contains(t, out.String(), "Usage of weserve:", args)
+		assert.Contains(t, out.String(), "-trace", args)
+		assert.Contains(t, out.String(), "diag", args)
+	}
+
+	out.Reset()
+	ResetForTesting(nil)
+	os.Args = []string{"cmd", "help", "weserve"}
+	assert.NoError(t, cli.Parse())
+	assert.NotContains(t, out.String(), "-trace")
+	assert.NotContains(t, out.String(), "-help-all")
+}
+
+func TestCommandAnywhere(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	var (
+		port int64
+		ran  string
+	)
+	for _, args := range [][]string{
+		{"cmd", "weserve", "config", "-port", "1"},
+		{"cmd", "extra", "weserve", "config", "-port", "1"},
+		{"cmd", "weserve", "extra", "config", "-port", "1"},
+	} {
+		ran, port = "", 0
+		ResetForTesting(nil)
+		cli = NewCli(nil, nil)
+		cli.TestMode = true
+		cli.Cmds = []*CLICommand{
+			{
+				Name: "weserve",
+				SubCommands: []*CLICommand{{
+					Name:   "config",
+					Action: func() { ran = "weserve config" },
+					Flags:  []CLIFlag{&Int64Flg{Variable: &port, Name: "port"}},
+				}},
+			},
+		}
+		os.Args = args
+		assert.NoError(t, cli.Parse(), args)
+		assert.Equal(t, "weserve config", ran, args)
+		assert.Equal(t, int64(1), port, args)
+	}
+}
+
+func TestBuiltinFirstPositional(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	pth := writeConfig(t, t.TempDir(), "app.toml", "[server]\nport = 1\n")
+	var (
+		out  bytes.Buffer
+		port int64
+		ran  bool
+	)
+	cli = NewCli(nil, nil)
+	cli.TestMode = true
+	cli.Writer = &out
+	cli.Cmds = []*CLICommand{{Name: "server", Action: func() { ran = true }, Flags: []CLIFlag{&Int64Flg{Variable: &port, Name: "port"}}}}
+
+	os.Args = []string{"cmd", "-config", pth, "config", "get", "server"}
+	assert.NoError(t, cli.Parse())
+	assert.False(t, ran)
+	assert.Equal(t, "{\"port\":1}\n", out.String())
+
+	// a builtin name after an application command is an argument of that command
+	ResetForTesting(nil)
+	os.Args = []string{"cmd", "-config", pth, "server", "config"}
+	assert.NoError(t, cli.Parse())
+	assert.True(t, ran)
+}
+
+func TestHelpHidesBuiltins(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	var out bytes.Buffer
+	cli = NewCli(nil, nil)
+	cli.TestMode = true
+	cli.Writer = &out
+	cli.Cmds = []*CLICommand{{Name: "serve", Usage: "serve things", Action: func() {}}}
+
+	os.Args = []string{"cmd", "-h"}
+	assert.NoError(t, cli.Parse())
+	assert.Contains(t, out.String(), "-config")
+	assert.NotContains(t, out.String(), "(default [])")
+	for _, s := range []string{"\n  completion", "\n  config ", "-profile", "-config-key", "-env-file", "-print-config", "-config-format"} {
+		assert.NotContains(t, out.String(), s)
+	}
+
+	out.Reset()
+	ResetForTesting(nil)
+	os.Args = []string{"cmd", "--help-all"}
+	assert.NoError(t, cli.Parse())
+	for _, s := range []string{"\n  completion", "\n  config ", "-profile", "-config-key", "-env-file", "-print-config", "-config-format"} {
+		assert.Contains(t, out.String(), s)
+	}
+}
@@ -0,0 +1,222 @@
+package mycli
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ValueSource supplies values for flags still holding their default after the command line is parsed.
+// flagPath is the flag's config key: "port" for a global flag, "server.port" for a command flag and
+// "weserve.config.port" for a subcommand flag. Lookup returns the value as it would be written on the
+// command line, lists comma separated, whether the source has one, and where it came from for debug output.
+type ValueSource interface {
+	Lookup(flagPath string) (value string, found bool, provenance string)
+}
+
+// sourceApplier is implemented by the built-in sources, they set a flag through its typed env or config
+// method instead of from the string Lookup returns
+type sourceApplier interface {
+	apply(f CLIFlag, flagPath string) (bool, error)
+}
+
+// configControlFlags the global flags that choose the config files, they are resolved from the sources
+// other than the config before the config is loaded
+var configControlFlags = map[string]bool{
+	"config":        true,
+	"config-format": true,
+	"no-config":     true,
+	"profile":       true,
+	"config-key":    true,
+}
+
+// DefaultSources the sources Parse uses when CLI.Sources is nil, env then config
+func (c *CLI) DefaultSources() []ValueSource {
+	return []ValueSource{c.EnvSource(), c.ConfigSource()}
+}
+
+// EnvSource the environment, and the dotenv files, read through each flag's EnvVar
+func (c *CLI) EnvSource() ValueSource {
+	return envSource{c}
+}
+
+// ConfigSource the config files Parse loaded, see Toml
+func (c *CLI) ConfigSource() ValueSource {
+	return configSource{c}
+}
+
+func (c *CLI) sources() []ValueSource {
+	if c.Sources == nil {
+		return c.DefaultSources()
+	}
+	return c.Sources
+}
+
+// hasConfigSource reports whether the config files take part in the chain
+func (c *CLI) hasConfigSource() bool {
+	for _, s := range c.sources() {
+		if _, ok := s.(configSource); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type envSource struct {
+	c *CLI
+}
+
+func (s envSource) Lookup(flagPath string) (string, bool, string) {
+	f := s.c.sourceFlag(flagPath)
+	if f == nil || len(f.GEnvVar()) == 0 {
+		return "", false, ""
+	}
+	if v, ok := LookupEnv(f.GEnvVar()); ok {
+		return v, true, "env " + f.GEnvVar()
+	}
+	if _, ok := f.(*SecretFlg); ok {
+		if v, ok := LookupEnv(f.GEnvVar() + "_FILE"); ok {
+			v, err := ReadSecret("@" + v)
+			return v, err == nil, "env " + f.GEnvVar() + "_FILE"
+		}
+	}
+	return "", false, ""
+}
+
+func (s envSource) apply(f CLIFlag, flagPath string) (bool, error) {
+	if len(f.GEnvVar()) == 0 {
+		return false, nil
+	}
+	_, found := LookupEnv(f.GEnvVar())
+	if _, ok := f.(*SecretFlg); ok && !found {
+		_, found = LookupEnv(f.GEnvVar() + "_FILE")
+	}
+	if !found {
+		return false, nil
+	}
+	return true, f.RetrieveEnvValue()
+}
+
+type configSource struct {
+	c *CLI
+}
+
+func (s configSource) Lookup(flagPath string) (string, bool, string) {
+	if !s.c.configLoaded {
+		return "", false, ""
+	}
+	v := Toml().Get(flagPath)
+	if v == nil {
+		return "", false, ""
+	}
+	if _, ok := v.([]interface{}); ok {
+		if l, err := CoerceStringList(v); err == nil {
+			return strings.Join(l, ","), true, Toml().Provenance(flagPath)
+		}
+		return configValue(v), true, Toml().Provenance(flagPath)
+	}
+	if _, ok := v.(map[string]interface{}); ok {
+		return configValue(v), true, Toml().Provenance(flagPath)
+	}
+	return fmt.Sprint(v), true, Toml().Provenance(flagPath)
+}
+
+func (s configSource) apply(f CLIFlag, flagPath string) (bool, error) {
+	if !s.c.configLoaded || !s.c.readConfigValue(f, flagPath) {
+		return false, nil
+	}
+	if err := f.RetrieveConfigValue(Toml(), flagPath); err != nil {
+		return true, err
+	}
+	if Debug && !GenerateBashCompletion {
+		log.Printf("- config file has flag %v value found of %v from %v", flagPath, f.GVariableToString(), Toml().Provenance(flagPath))
+	}
+	return true, nil
+}
+
+// MapSource an in-memory source keyed by flag path, for tests or values an application fetched itself
+type MapSource map[string]string
+
+func (m MapSource) Lookup(flagPath string) (string, bool, string) {
+	v, ok := m[flagPath]
+	return v, ok, "map"
+}
+
+// DirSource a directory holding one file per flag path, such as mounted Docker or Kubernetes secrets,
+// trailing newlines are removed
+type DirSource string
+
+func (d DirSource) Lookup(flagPath string) (string, bool, string) {
+	pth := filepath.Join(FixPath(string(d)), flagPath)
+	byt, err := os.ReadFile(pth)
+	if err != nil {
+		return "", false, ""
+	}
+	return strings.TrimRight(string(byt), "\r\n"), true, pth
+}
+
+// sourceFlag the flag a flag path names
+func (c *CLI) sourceFlag(flagPath string) CLIFlag {
+	keys := strings.Split(flagPath, ".")
+	flgs, cmds := c.Flgs, c.Cmds
+	for _, k := range keys[:len(keys)-1] {
+		cmd := configCommand(cmds, k)
+		if cmd == nil {
+			return nil
+		}
+		flgs, cmds = cmd.Flags, cmd.SubCommands
+	}
+	return configFlag(flgs, keys[len(keys)-1])
+}
+
+// applySources sets the flags still holding their default from the first source that has a value,
+// the config source is left out until the config is loaded
+func (c *CLI) applySources(flgs []CLIFlag, prefix string, withConfig bool) error {
+	srcs := c.sources()
+	for _, f := range flgs {
+		pth := prefix + f.GName()
+		for _, src := range srcs {
+			if _, ok := src.(configSource); ok && !withConfig {
+				continue
+			}
+			found, err := applySource(src, f, pth)
+			if err != nil {
+				log.Printf("!!! issue retrieving flag value %v\n", err)
+				return err
+			}
+			if found {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// applySource sets one flag from a source, a value the flag cannot take is an error naming the source
+func applySource(src ValueSource, f CLIFlag, flagPath string) (bool, error) {
+	if a, ok := src.(sourceApplier); ok {
+		return a.apply(f, flagPath)
+	}
+	s, found, prov := src.Lookup(flagPath)
+	if !found {
+		return false, nil
+	}
+	v, err := flagConfigValue(f, s)
+	if err != nil {
+		return true, fmt.Errorf("%s: %s: %v", prov, flagPath, err)
+	}
+	var tw TomlWrapper
+	tw.Reset()
+	if err = tw.Set(flagPath, v); err != nil {
+		return true, fmt.Errorf("%s: %v", prov, err)
+	}
+	if err = f.RetrieveConfigValue(&tw, flagPath); err != nil {
+		return true, fmt.Errorf("%s: %v", prov, err)
+	}
+	if Debug && !GenerateBashCompletion {
+		log.Printf("- source has flag %v value found of %v from %v", flagPath, f.GVariableToString(), prov)
+	}
+	return true, nil
+}
@@ -0,0 +1,101 @@
+package mycli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueSources(t *testing.T) {
+	teardownTestCase := setupTestCase(t)
+	defer teardownTestCase(t)
+
+	dir := t.TempDir()
+	pth := writeConfig(t, dir, "app.toml", "host = \"config\"\nratio = 0.25\n\n[server]\nport = 8080\n")
+	secrets := filepath.Join(dir, "secrets")
+	assert.NoError(t, os.MkdirAll(secrets, 0700))
+	assert.NoError(t, os.WriteFile(filepath.Join(secrets, "token"), []byte("s3cret\n"), 0600))
+	os.Setenv("T_HOST", "env")
+	defer os.Unsetenv("T_HOST")
+
+	var (
+		host, token string
+		ratio       float64
+		port        int64
+		hosts       StringList
+	)
+	parse := func(sources func(c *CLI) []ValueSource, args ...string) error {
+		ResetForTesting(nil)
+		cli = NewCli(nil, nil)
+		cli.TestMode = true
+		cli.DisableEnvVars = false
+		cli.Flgs = []CLIFlag{
+			&StringFlg{Variable: &host, Name: "host"},
+			&Float64Flg{Variable: &ratio, Name: "ratio"},
+			&SecretFlg{Variable: &token, Name: "token"},
+		}
+		cli.Cmds = []*CLICommand{{
+			Name:   "server",
+			Action: func() {},
+			Flags: []CLIFlag{
+				&Int64Flg{Variable: &port, Name: "port", Value: 80},
+				&VarFlg{Variable: &hosts, Name: "hosts"},
+			},
+		}}
+		if sources != nil {
+			cli.Sources = sources(cli)
+		}
+		os.Args = append([]string{"cmd"}, args...)
+		return cli.Parse()
+	}
+
+	assert.NoError(t, parse(nil, "-c", pth, "server"))
+	assert.Equal(t, "env", host)
+	assert.Equal(t, 0.25, ratio)
+	assert.Equal(t, int64(8080), port)
+
+	// config before env
+	assert.NoError(t, parse(func(c *CLI) []ValueSource {
+		return []ValueSource{c.ConfigSource(), c.EnvSource()}
+	}, "-c", pth, "server"))
+	assert.Equal(t, "config", host)
+
+	// an in-memory source ahead of both, the command line still wins
+	kv := MapSource{"host": "map", "ratio": "0.5", "server.port": "9091", "server.hosts": "a,b", "config": pth}
+	assert.NoError(t, parse(func(c *CLI) []ValueSource {
+		return append([]ValueSource{kv, DirSource(secrets)}, c.DefaultSources()...)
+	}, "-ratio", "0.75", "server"))
+	assert.Equal(t, "map", host)
+	assert.Equal(t, 0.75, ratio)
+	assert.Equal(t, int64(9091), port)
+	assert.Equal(t, StringList{"a", "b"}, hosts)
+	assert.Equal(t, "s3cret", token)
+	assert.Equal(t, []string{pth}, Toml().Files)
+
+	// without the config source the files are loaded but not read
+	assert.NoError(t, parse(func(c *CLI) []ValueSource {
+		return []ValueSource{c.EnvSource()}
+	}, "-c", pth, "server"))
+	assert.Equal(t, int64(80), port)
+	assert.Equal(t, 0.0, ratio)
+
+	err := parse(func(c *CLI) []ValueSource {
+		return []ValueSource{MapSource{"server.port": "many"}}
+	}, "server")
+	assert.EqualError(t, err, `map: server.port: expected an integer, got string "many"`)
+
+	v, ok, prov := cli.EnvSource().Lookup("host")
+	assert.True(t, ok)
+	assert.Equal(t, "env", v)
+	assert.Equal(t, "env T_HOST", prov)
+
+	assert.NoError(t, parse(nil, "-c", pth, "server"))
+	v, ok, prov = cli.ConfigSource().Lookup("server.port")
+	assert.True(t, ok)
+	assert.Equal(t, "8080", v)
+	assert.Equal(t, pth, prov)
+	_, ok, _ = cli.ConfigSource().Lookup("server.hosts")
+	assert.False(t, ok)
+}